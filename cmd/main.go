@@ -2,20 +2,49 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
-	"time"
 
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/cli"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/config"
 	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
 	"github.com/Jawadh-Salih/go-web-analyzer/internal/server"
 )
 
 func main() {
+	// "analyze <url>" analyzes the url without the server and exits
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := cli.Analyze(ctx, os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+
+	// the config is validated before anything starts
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		os.Exit(2)
+	}
+
 	// enable logging and inject here.
-	logger := logger.New()
+	logger := logger.New(cfg.Log.Level)
+
+	analyzer, err := analyzer.New(cfg.AnalyzerConfig())
+	if err != nil {
+		logger.Error("Failed to create the analyzer", "error", err)
+		os.Exit(1)
+	}
 
-	svr := server.New(":8080", logger, true)
-	logger.Info("Starting server...", "addr", ":8080")
+	svr := server.New(cfg.ServerConfig(), analyzer, logger)
+	logger.Info("Starting server...", "addr", cfg.Server.Addr)
 
 	go func() {
 		if err := svr.Start(); err != nil {
@@ -28,7 +57,7 @@ func main() {
 	signal.Notify(done, os.Interrupt)
 	<-done
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := svr.Stop(ctx); err != nil {
 		logger.Error("Server shutdown failed", "error", err)
@@ -24,3 +24,16 @@ func NewAppError(code int, message string) *AppError {
 		Message: message,
 	}
 }
+
+// LimitError is returned when a fetched page goes over one of the limits.
+type LimitError struct {
+	*AppError
+	Limit string // name of the limit which was hit
+}
+
+func NewLimitError(limit string, code int, message string) *LimitError {
+	return &LimitError{
+		AppError: NewAppError(code, message),
+		Limit:    limit,
+	}
+}
@@ -3,35 +3,44 @@ package analyzer
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"math"
 	"net/http"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 
-	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
 	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
 	"golang.org/x/net/html"
 )
 
 // This will analyze the request url.
 type AnalyzerRequest struct {
-	Url string `json:"url" binding:"required,url"`
+	Url      string   `json:"url" form:"url" binding:"required,url"`
+	Analyses []string `json:"analyses" form:"analyses"` // analyses to run, all of them when empty
+	Exclude  []string `json:"exclude" form:"exclude"`   // analyses to skip
+
+	RateLimit *RateLimitOptions `json:"rate_limit" form:"-"` // link check limits of this analysis on top of the configured ones
 }
 type AnalyzerResponse struct {
-	HtmlVersion  string               // HTML version
-	PageTitle    string               // Page title
-	Headings     map[string]int       // Headings count
-	LinkSummary  *LinkSummaryResponse // Links
-	HasLoginForm bool                 // true if the page has a login form
-	Errors       []string             // Errors encountered during analysis
-	err          error
+	HtmlVersion     string                // HTML version
+	DocumentMode    string                // no-quirks, limited-quirks or quirks, the mode browsers render the page in
+	PageTitle       string                // Page title
+	Headings        map[string]int        // Headings count
+	HeadingOutline  []Heading             // Headings in document order
+	HeadingFindings []Finding             // Issues with the heading structure
+	LinkSummary     *LinkSummaryResponse  // Links
+	HasLoginForm    bool                  // true if the page has a login form
+	AuthForms       []AuthForm            // Login, signup, password reset and MFA forms
+	SSOButtons      []SSOButton           // Buttons to sign in with another provider
+	SEO             *SEOResult            // Search engine and social network metadata
+	StructuredData  *StructuredDataResult // schema.org entities of the JSON-LD, Microdata and RDFa
+	Accessibility   *AccessibilityResult  // Accessibility findings and score
+	Encoding        string                // Character encoding the page was decoded from
+	Analyses        []string              // Analyses which ran
+	TimedOut        []string              // Analyses which didn't finish in time, their results are partial or missing
+	Errors          []string              // Errors encountered during analysis
+	Extensions      map[string]any        // Results of custom extractors by name
 }
 
 type LinkSummaryResponse struct {
@@ -40,142 +49,230 @@ type LinkSummaryResponse struct {
 	ExternalLinks     int
 	AccessibleLinks   int
 	InaccessibleLinks int
+	DisallowedLinks   int            // Links not checked because robots.txt disallows them
+	UncheckedLinks    int            // Relative links not checked because there's no base url to resolve them
+	Categories        map[string]int // Links count by their check category
 }
 
 type Link struct {
-	LinkType   string // internal or external
-	LinkUrl    string // url
-	Accessible bool   // true if the link is inaccessible
+	LinkType   string     // internal or external
+	LinkUrl    string     // url
+	Accessible bool       // true if the link is accessible
+	Status     LinkStatus // result of the accessibility check
+	Position   int        // position of the element among all elements of the document
+	Text       string     // anchor text
+	Rel        []string   // values of the rel attribute
+}
+
+// Analyzer fetches pages and runs the registered extractors against them.
+type Analyzer struct {
+	registry    *Registry
+	pageClient  *http.Client
+	linkClient  *http.Client
+	linkChecker *LinkChecker
+	robots      *RobotsPolicy // nil when robots.txt isn't respected
+
+	// limits of the page fetch
+	maxBodySize           int64
+	maxHeaderSize         int64
+	maxRedirects          int
+	maxDecompressionRatio float64
+}
+
+func New(config Config) (*Analyzer, error) {
+	pageClient, linkClient, err := newClients(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache lives with the analyzer so it's shared across analyses
+	linkCache, err := newLinkCache(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var robots *RobotsPolicy
+	if config.RespectRobots {
+		robots = NewRobotsPolicy(linkClient, config.UserAgent, config.RobotsCacheTTL)
+	}
 
+	return &Analyzer{
+		registry:   defaultRegistry,
+		pageClient: pageClient,
+		linkClient: linkClient,
+		linkChecker: NewLinkChecker(linkClient, LinkCheckerOptions{
+			Cache:         linkCache,
+			RateLimit:     config.RateLimit,
+			MaxRetryAfter: config.MaxRetryAfter,
+			Robots:        robots,
+		}),
+		robots:                robots,
+		maxBodySize:           config.MaxBodySize,
+		maxHeaderSize:         config.MaxHeaderSize,
+		maxRedirects:          config.MaxRedirects,
+		maxDecompressionRatio: config.MaxDecompressionRatio,
+	}, nil
 }
 
-var analyzerLogger *slog.Logger
+var defaultAnalyzer *Analyzer
 
+func init() {
+	var err error
+	if defaultAnalyzer, err = New(DefaultConfig()); err != nil {
+		panic(err)
+	}
+}
+
+// Default returns the analyzer with the default configuration, the one the package level functions use.
+func Default() *Analyzer {
+	return defaultAnalyzer
+}
+
+// Analyze runs the request with the default configuration.
 func Analyze(ctx context.Context, request AnalyzerRequest) (*AnalyzerResponse, error) {
-	analyzerLogger = logger.FromContext(ctx)
-	result := AnalyzerResponse{
-		Errors: make([]string, 0),
+	return defaultAnalyzer.Analyze(ctx, request)
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, request AnalyzerRequest) (*AnalyzerResponse, error) {
+	result, _, err := a.analyze(ctx, request, a.requestLinkChecker(request.RateLimit))
+	return result, err
+}
+
+// requestLinkChecker returns the link checker of a request, with the request's own limits if it has any.
+// A crawl or a batch makes one for all of its pages so they share the limits.
+func (a *Analyzer) requestLinkChecker(rateLimit *RateLimitOptions) *LinkChecker {
+	if rateLimit == nil {
+		return a.linkChecker
 	}
+	return a.linkChecker.WithRateLimit(*rateLimit)
+}
+
+// analyze also returns the parsed page, so the crawler can follow its links.
+// The links are checked with the given checker, the rate limit of the request isn't looked at.
+func (a *Analyzer) analyze(ctx context.Context, request AnalyzerRequest, linkChecker *LinkChecker) (*AnalyzerResponse, *html.Node, error) {
+	analyzerLogger := logger.FromContext(ctx)
 
 	pageUrl, err := url.Parse(request.Url)
 	if err != nil {
-		return nil, fmt.Errorf("invalid URL syntax: %w", err)
+		return nil, nil, fmt.Errorf("invalid URL syntax: %w", err)
 	}
 
-	resp, err := http.Get(request.Url)
+	// pick the analyses up front so an unknown one fails before the page is fetched
+	extractors, err := selectExtractors(a.registry.Extractors(), request.Analyses, request.Exclude)
 	if err != nil {
-		analyzerLogger.Error("Error on reach the URL", slog.String("url", request.Url), slog.Any("error", err))
-		return nil, err
+		analyzerLogger.Error("Invalid analyses", slog.Any("error", err))
+		return nil, nil, err
 	}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		analyzerLogger.Error("Error Accessing URL", slog.String("url", request.Url), slog.Int("status", resp.StatusCode))
-		return nil, apperrors.NewAppError(
-			resp.StatusCode,
-			fmt.Sprintf("Error on Accessing URL: %s", request.Url),
-		)
+	if a.robots != nil && !a.robots.Allowed(ctx, request.Url) {
+		analyzerLogger.Error("URL disallowed by robots.txt", slog.String("url", request.Url))
+		return nil, nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, request.Url)
 	}
 
-	analyzerLogger.Debug("Response", slog.Any("response", resp))
-
-	body, err := io.ReadAll(resp.Body)
+	body, header, err := a.fetchPage(ctx, analyzerLogger, request.Url)
 	if err != nil {
-		analyzerLogger.Error("Failed to read response body", slog.Any("error", err))
-		return nil, err
+		return nil, nil, err
+	}
 
+	return a.analyzeBody(ctx, pageUrl, body, header, extractors, linkChecker)
+}
+
+// analyzeBody runs the extractors against the page, however it was obtained.
+// The header is the one of the response, only the content type is known for HTML which wasn't fetched.
+func (a *Analyzer) analyzeBody(ctx context.Context, pageUrl *url.URL, body []byte, header http.Header, extractors []Extractor, linkChecker *LinkChecker) (*AnalyzerResponse, *html.Node, error) {
+	analyzerLogger := logger.FromContext(ctx)
+
+	// the extractors only ever see UTF-8
+	contentType := header.Get("Content-Type")
+	body, encoding, err := decodeBody(body, contentType)
+	if err != nil {
+		analyzerLogger.Error("failed to decode the page", slog.String("encoding", encoding), slog.Any("error", err))
+		return nil, nil, err
 	}
 
-	// check for html content type
-	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
-		err := fmt.Errorf("Invalid response: %s", resp.Header.Get("Content-Type"))
-		analyzerLogger.Error(err.Error(), slog.String("content-type", resp.Header.Get("Content-Type")))
-		return nil, err
+	result := AnalyzerResponse{
+		Encoding: encoding,
+		Analyses: make([]string, 0),
+		TimedOut: make([]string, 0),
+		Errors:   make([]string, 0),
 	}
 
 	rootNode, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
 		analyzerLogger.Error("failed to parse HTML", slog.Any("error", err))
-		return nil, err
+		return nil, nil, err
 	}
 
-	// following can be done in parallel
-	// number 5 can be taken into configs based on the different anaylysis we need from the analyzer
-	resultChan := make(chan AnalyzerResponse, 5)
+	page := &Page{Url: pageUrl, Body: body, ContentType: contentType, Header: header, Client: a.linkClient, LinkChecker: linkChecker}
+	for _, extractor := range extractors {
+		result.Analyses = append(result.Analyses, extractor.Name())
+	}
+
+	// every extractor runs in parallel, the buffer makes sure none of them blocks on send.
+	resultChan := make(chan extractorResult, len(extractors))
 	var wg sync.WaitGroup
 
-	// -   What HTML version has the document?
-	wg.Add(5)
-	go func() {
-		startTime := time.Now()
-		defer wg.Done()
-		buffer := int(math.Min(float64(len(body)), 2048))
-		htmlSnippet := string(body[:buffer])
-		status := "Success"
-		if htmlSnippet == "" {
-			resultChan <- AnalyzerResponse{err: errors.New("empty HTML snippet")}
-			status = "Fail"
-			return
-		}
+	wg.Add(len(extractors))
+	for _, extractor := range extractors {
+		go func() {
+			defer wg.Done()
+			resultChan <- runExtractor(ctx, analyzerLogger, extractor, rootNode, page)
+		}()
+	}
 
-		htmlV := detectHTMLVersion(htmlSnippet)
-		resultChan <- AnalyzerResponse{HtmlVersion: htmlV}
-
-		duration := time.Since(startTime).Nanoseconds()
-		functionName := "HtmlVersion Check"
-		analyzerLogger.Info("Function Executed",
-			slog.String("function", functionName),
-			slog.String("status", status),
-			slog.Int64("duration", duration),
-		)
-
-		observability.
-			DurationMetrics.
-			WithLabelValues(functionName, status).
-			Observe(float64(duration))
+	// Close the result channel after all goroutines are done
+	go func() {
+		wg.Wait()
+		close(resultChan)
 	}()
 
-	// -   What is the page title?
-	go ExtractTitle(rootNode, &wg, resultChan)
+	pending := make(map[string]bool, len(extractors))
+	for _, extractor := range extractors {
+		pending[extractor.Name()] = true
+	}
 
-	// -   How many headings of what level are in the document?
-	go ExtractHeadings(rootNode, &wg, resultChan)
+	progress := progressFromContext(ctx)
+	collectResults(ctx, resultChan, func(res extractorResult) {
+		delete(pending, res.name)
+		res.apply(&result)
+		progress(Progress{Analysis: res.name, Result: result.snapshot()})
+	})
 
-	// -   How many internal and external links are in the document? Are there any inaccessible links and how many?
-	go ExtrackLinks(rootNode, pageUrl, &wg, resultChan)
+	// whatever is still pending didn't make it before the deadline
+	for _, extractor := range extractors {
+		if pending[extractor.Name()] {
+			result.TimedOut = append(result.TimedOut, extractor.Name())
+		}
+	}
 
-	// -   Does the page contain a login form?
-	go ExtractLoginForm(rootNode, &wg, resultChan)
+	return &result, rootNode, nil
+}
 
-	// Close the result channel after all goroutines are done
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+// extractors get a short grace period to hand back partial results once the context is done
+const partialResultGrace = 100 * time.Millisecond
 
-	for res := range resultChan {
-		if res.err != nil {
-			result.Errors = append(result.Errors, res.err.Error())
-			continue
-		} else {
-			if res.HtmlVersion != "" {
-				result.HtmlVersion = res.HtmlVersion
-			}
-			if res.PageTitle != "" {
-				result.PageTitle = res.PageTitle
-			}
-			if len(res.Headings) > 0 {
-				result.Headings = res.Headings
+// collectResults reads the results until all extractors are done or the context ends.
+func collectResults(ctx context.Context, resultChan <-chan extractorResult, collect func(extractorResult)) {
+	for {
+		select {
+		case res, ok := <-resultChan:
+			if !ok {
+				return
 			}
-			if res.LinkSummary != nil && len(res.LinkSummary.Links) > 0 {
-				result.LinkSummary = res.LinkSummary
+			collect(res)
+		case <-ctx.Done():
+			grace := time.After(partialResultGrace)
+			for {
+				select {
+				case res, ok := <-resultChan:
+					if !ok {
+						return
+					}
+					collect(res)
+				case <-grace:
+					return
+				}
 			}
-			if res.HasLoginForm {
-				result.HasLoginForm = res.HasLoginForm
-			}
-
 		}
 	}
-
-	return &result, nil
 }
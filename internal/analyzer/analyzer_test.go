@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -233,3 +234,108 @@ func TestAnalyze_ValidUrl_WithLoginForm(t *testing.T) {
 	})
 
 }
+
+func TestAnalyze_SelectedAnalyses(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+
+		file, err := os.ReadFile("./testdata/extract_links.html")
+		if err != nil {
+			log.Fatalf("Error on file %s", err.Error())
+		}
+
+		w.Write(file)
+	}))
+	defer testServer.Close()
+
+	t.Run("Only the requested analyses run", func(t *testing.T) {
+		request := AnalyzerRequest{Url: testServer.URL, Analyses: []string{TitleAnalysis, HeadingsAnalysis}}
+
+		response, err := Analyze(context.Background(), request)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{TitleAnalysis, HeadingsAnalysis}, response.Analyses)
+		assert.Equal(t, "Sample Links", response.PageTitle)
+		assert.NotEmpty(t, response.Headings)
+		assert.Empty(t, response.HtmlVersion)
+		assert.Nil(t, response.LinkSummary)
+	})
+
+	t.Run("Excluded analyses are skipped", func(t *testing.T) {
+		request := AnalyzerRequest{Url: testServer.URL, Exclude: []string{LinksAnalysis}}
+
+		response, err := Analyze(context.Background(), request)
+		assert.NoError(t, err)
+		assert.NotContains(t, response.Analyses, LinksAnalysis)
+		assert.Contains(t, response.Analyses, TitleAnalysis)
+		assert.Equal(t, "HTML5", response.HtmlVersion)
+		assert.Nil(t, response.LinkSummary)
+	})
+
+	t.Run("Unknown analysis is rejected", func(t *testing.T) {
+		request := AnalyzerRequest{Url: testServer.URL, Analyses: []string{"unknown"}}
+
+		response, err := Analyze(context.Background(), request)
+		assert.ErrorIs(t, err, ErrUnknownAnalysis)
+		assert.Nil(t, response)
+	})
+}
+
+func TestAnalyze_Timeout(t *testing.T) {
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slowServer.Close()
+
+	t.Run("Page fetch is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		response, err := Analyze(ctx, AnalyzerRequest{Url: slowServer.URL})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, response)
+	})
+
+	t.Run("Link checks are cancelled and reported as timed out", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `<!DOCTYPE html><html><head><title>Slow Links</title></head><body><a href="%s/slow">Slow</a></body></html>`, slowServer.URL)
+		}))
+		defer testServer.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		response, err := Analyze(ctx, AnalyzerRequest{Url: testServer.URL})
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, "Slow Links", response.PageTitle)
+		assert.Equal(t, "HTML5", response.HtmlVersion)
+		assert.Equal(t, []string{LinksAnalysis}, response.TimedOut)
+		assert.Nil(t, response.LinkSummary)
+		assert.Empty(t, response.Errors)
+	})
+}
+
+func TestAnalyzer_XHTML(t *testing.T) {
+	page := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>XHTML</title></head><body></body></html>`)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xhtml+xml")
+		w.Write(page)
+	}))
+	defer testServer.Close()
+
+	response, err := Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL, Analyses: []string{TitleAnalysis, HtmlVersionAnalysis}})
+	assert.NoError(t, err)
+	assert.Equal(t, "XHTML", response.PageTitle)
+	assert.Equal(t, "XHTML 1.0 Transitional", response.HtmlVersion)
+	assert.Equal(t, NoQuirksMode, response.DocumentMode)
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+)
+
+const (
+	MaxBatchUrls = 50
+	// urls analyzed at once
+	batchConcurrency = 4
+)
+
+// ErrTooManyUrls is returned when a batch has more than MaxBatchUrls urls.
+var ErrTooManyUrls = fmt.Errorf("a batch takes up to %d urls", MaxBatchUrls)
+
+// This will analyze every url of the request with the same options.
+type BatchRequest struct {
+	Urls     []string `json:"urls" binding:"required,min=1,dive,required,url"` // up to MaxBatchUrls, Batch checks it
+	Analyses []string `json:"analyses"`                                        // analyses to run on every url, all of them when empty
+	Exclude  []string `json:"exclude"`                                         // analyses to skip
+
+	RateLimit *RateLimitOptions `json:"rate_limit"` // link check limits of the batch on top of the configured ones
+}
+
+type BatchResponse struct {
+	Results  []BatchResult // Results in the order of the request urls
+	Failed   int           // Urls which couldn't be analyzed
+	TimedOut bool          // true if the batch stopped before it was done
+}
+
+type BatchResult struct {
+	Url        string
+	Result     *AnalyzerResponse // nil when the url couldn't be analyzed
+	Error      string
+	StatusCode int // status of the page when it couldn't be accessed
+}
+
+// Batch runs the request with the default configuration.
+func Batch(ctx context.Context, request BatchRequest) (*BatchResponse, error) {
+	return defaultAnalyzer.Batch(ctx, request)
+}
+
+// Batch analyzes the urls a few at a time. A url failing only records its error,
+// only invalid analyses fail the whole batch.
+func (a *Analyzer) Batch(ctx context.Context, request BatchRequest) (*BatchResponse, error) {
+	analyzerLogger := logger.FromContext(ctx)
+
+	if len(request.Urls) > MaxBatchUrls {
+		return nil, ErrTooManyUrls
+	}
+
+	// the analyses are the same for every url, so they're checked once up front
+	if _, err := selectExtractors(a.registry.Extractors(), request.Analyses, request.Exclude); err != nil {
+		analyzerLogger.Error("Invalid analyses", slog.Any("error", err))
+		return nil, err
+	}
+
+	// the limits are the batch's, not of every url on its own
+	linkChecker := a.requestLinkChecker(request.RateLimit)
+
+	response := &BatchResponse{Results: make([]BatchResult, len(request.Urls))}
+	indexes := make(chan int, len(request.Urls))
+	for i := range request.Urls {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for range min(batchConcurrency, len(request.Urls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every worker writes only to the results of its own indexes
+			for i := range indexes {
+				response.Results[i] = a.batchResult(ctx, request, request.Urls[i], linkChecker)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range response.Results {
+		if result.Result == nil {
+			response.Failed++
+		}
+	}
+	response.TimedOut = ctx.Err() != nil
+
+	analyzerLogger.Info("Batch finished",
+		slog.Int("urls", len(request.Urls)),
+		slog.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+func (a *Analyzer) batchResult(ctx context.Context, request BatchRequest, url string, linkChecker *LinkChecker) BatchResult {
+	result, _, err := a.analyze(ctx, AnalyzerRequest{
+		Url:      url,
+		Analyses: request.Analyses,
+		Exclude:  request.Exclude,
+	}, linkChecker)
+	if err == nil {
+		return BatchResult{Url: url, Result: result}
+	}
+
+	batchResult := BatchResult{Url: url, Error: err.Error()}
+	if httpErr, ok := err.(apperrors.HttpError); ok {
+		batchResult.StatusCode = httpErr.StatusCode()
+	}
+
+	return batchResult
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	testServer := crawlServer()
+	defer testServer.Close()
+
+	t.Run("Results are in the order of the urls", func(t *testing.T) {
+		urls := []string{
+			testServer.URL + "/b",
+			testServer.URL + "/missing",
+			testServer.URL,
+			"http://unknown-host.invalid",
+			testServer.URL + "/a",
+		}
+
+		response, err := Batch(context.Background(), BatchRequest{Urls: urls, Analyses: []string{TitleAnalysis}})
+		assert.NoError(t, err)
+		assert.False(t, response.TimedOut)
+		assert.Equal(t, 2, response.Failed)
+		assert.Len(t, response.Results, len(urls))
+
+		for i, result := range response.Results {
+			assert.Equal(t, urls[i], result.Url)
+		}
+
+		assert.Equal(t, "/b", response.Results[0].Result.PageTitle)
+		assert.Equal(t, "/", response.Results[2].Result.PageTitle)
+		assert.Equal(t, "/a", response.Results[4].Result.PageTitle)
+
+		// the page error only fails its own url
+		assert.Nil(t, response.Results[1].Result)
+		assert.Equal(t, http.StatusNotFound, response.Results[1].StatusCode)
+		assert.Contains(t, response.Results[1].Error, "Error on Accessing URL")
+
+		assert.Nil(t, response.Results[3].Result)
+		assert.Zero(t, response.Results[3].StatusCode)
+		assert.NotEmpty(t, response.Results[3].Error)
+	})
+
+	t.Run("Unknown analysis fails the batch", func(t *testing.T) {
+		_, err := Batch(context.Background(), BatchRequest{Urls: []string{testServer.URL}, Analyses: []string{"unknown"}})
+		assert.ErrorIs(t, err, ErrUnknownAnalysis)
+	})
+
+	t.Run("Too many urls fail the batch", func(t *testing.T) {
+		urls := make([]string, MaxBatchUrls+1)
+		for i := range urls {
+			urls[i] = testServer.URL
+		}
+
+		_, err := Batch(context.Background(), BatchRequest{Urls: urls})
+		assert.ErrorIs(t, err, ErrTooManyUrls)
+	})
+}
+
+func TestBatch_RateLimitIsShared(t *testing.T) {
+	linkServer, highest := concurrencyServer(20 * time.Millisecond)
+	defer linkServer.Close()
+
+	siteServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := ""
+		for i := range 3 {
+			content += fmt.Sprintf(`<a href="%s%s/%d">Link</a>`, linkServer.URL, r.URL.Path, i)
+		}
+		fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>%s</title></head><body>%s</body></html>", r.URL.Path, content)
+	}))
+	defer siteServer.Close()
+
+	response, err := Batch(context.Background(), BatchRequest{
+		Urls:      []string{siteServer.URL + "/1", siteServer.URL + "/2", siteServer.URL + "/3", siteServer.URL + "/4"},
+		Analyses:  []string{LinksAnalysis},
+		RateLimit: &RateLimitOptions{MaxConcurrentPerHost: 1},
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, response.Failed)
+
+	// the urls are analyzed at once, their link checks still go one at a time
+	assert.Equal(t, int32(1), highest.Load())
+}
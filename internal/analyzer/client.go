@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Config configures the outbound HTTP client which fetches the page and checks its links.
+type Config struct {
+	Timeout               time.Duration     // timeout of the page fetch
+	LinkTimeout           time.Duration     // timeout of a single link check
+	MaxRedirects          int               // redirects followed before giving up
+	UserAgent             string            // User-Agent header sent with every request
+	Proxy                 string            // proxy url, the environment proxy is used when empty
+	InsecureSkipVerify    bool              // skip TLS certificate verification
+	TLSConfig             *tls.Config       // custom TLS settings such as root CAs
+	MaxConnsPerHost       int               // connections kept open per host, 0 means no limit
+	Transport             http.RoundTripper // replaces the default transport, handy for tests
+	LinkCacheTTL          time.Duration     // how long a link check result is reused
+	LinkCacheSize         int               // link check results kept in memory, 0 disables the cache
+	LinkCacheFile         string            // keeps the link check results in this file instead of memory
+	LinkCache             LinkCache         // custom store for the link check results
+	RateLimit             RateLimitOptions  // limits of the link checks per host, shared by all analyses
+	MaxRetryAfter         time.Duration     // longest Retry-After of a rate limited link which is waited for
+	RespectRobots         bool              // skip the page and links robots.txt disallows for the user agent
+	RobotsCacheTTL        time.Duration     // how long the robots.txt of a host is reused
+	Egress                *EgressPolicy     // where the analyzer may connect to, anywhere when nil
+	MaxBodySize           int64             // largest page body read, decompressed, 0 means no limit
+	MaxHeaderSize         int64             // largest response headers read
+	MaxDecompressionRatio float64           // largest ratio of the decompressed page to the compressed one, 0 means no limit
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:         10 * time.Second,
+		LinkTimeout:     3 * time.Second,
+		MaxRedirects:    10,
+		UserAgent:       "go-web-analyzer",
+		MaxConnsPerHost: 10,
+		LinkCacheTTL:    10 * time.Minute,
+		LinkCacheSize:   10000,
+		// the request rate isn't limited by default, a page with many internal links would take too long
+		RateLimit: RateLimitOptions{
+			MaxConcurrentPerHost: 4,
+		},
+		MaxRetryAfter:         5 * time.Second,
+		RobotsCacheTTL:        time.Hour,
+		MaxBodySize:           5 << 20,
+		MaxHeaderSize:         64 << 10,
+		MaxDecompressionRatio: 100,
+	}
+}
+
+// newLinkCache picks the link cache of the config, nil when caching is disabled.
+func newLinkCache(config Config) (LinkCache, error) {
+	switch {
+	case config.LinkCache != nil:
+		return config.LinkCache, nil
+	case config.LinkCacheFile != "":
+		return NewFileCache(config.LinkCacheFile, config.LinkCacheTTL)
+	case config.LinkCacheSize > 0 && config.LinkCacheTTL > 0:
+		return NewMemoryCache(config.LinkCacheTTL, config.LinkCacheSize), nil
+	default:
+		return nil, nil
+	}
+}
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// newClients creates the page and link clients.
+// Both share the same transport so connections are reused across all links of an analysis.
+func newClients(config Config) (*http.Client, *http.Client, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config.UserAgent != "" {
+		transport = &userAgentTransport{userAgent: config.UserAgent, next: transport}
+	}
+
+	// every hop of a redirect goes through the transport, so the redirects are checked too
+	if config.Egress != nil {
+		transport = &egressTransport{policy: config.Egress, next: transport}
+	}
+
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) > config.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects: %w", config.MaxRedirects, ErrTooManyRedirects)
+		}
+		return nil
+	}
+
+	pageClient := &http.Client{
+		Transport:     transport,
+		Timeout:       config.Timeout,
+		CheckRedirect: checkRedirect,
+	}
+
+	linkClient := &http.Client{
+		Transport:     transport,
+		Timeout:       config.LinkTimeout,
+		CheckRedirect: checkRedirect,
+	}
+
+	return pageClient, linkClient, nil
+}
+
+func newTransport(config Config) (http.RoundTripper, error) {
+	if config.Transport != nil {
+		return config.Transport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Proxy != "" {
+		proxyUrl, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	tlsConfig := &tls.Config{}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+	if config.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if config.Egress != nil {
+		transport.DialContext = egressDialer(config.Egress).DialContext
+	}
+
+	if config.MaxHeaderSize > 0 {
+		transport.MaxResponseHeaderBytes = config.MaxHeaderSize
+	}
+
+	if config.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+		transport.MaxIdleConnsPerHost = config.MaxConnsPerHost
+	}
+
+	return transport, nil
+}
+
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
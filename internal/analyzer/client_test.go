@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAnalyzer_StandInTransport(t *testing.T) {
+	var mu sync.Mutex
+	userAgents := make(map[string]string)
+
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		userAgents[req.Method+" "+req.URL.String()] = req.Header.Get("User-Agent")
+		mu.Unlock()
+
+		body := ""
+		if req.URL.Path == "/" {
+			body = `<!DOCTYPE html><html><head><title>Stand In</title></head><body><a href="/about">About</a></body></html>`
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	config := DefaultConfig()
+	config.UserAgent = "analyzer-test"
+	config.Transport = transport
+
+	a, err := New(config)
+	assert.NoError(t, err)
+
+	response, err := a.Analyze(context.Background(), AnalyzerRequest{Url: "http://stand-in.test/"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Stand In", response.PageTitle)
+	assert.Equal(t, 1, response.LinkSummary.AccessibleLinks)
+
+	assert.Equal(t, map[string]string{
+		"GET http://stand-in.test/":       "analyzer-test",
+		"HEAD http://stand-in.test/about": "analyzer-test",
+	}, userAgents)
+}
+
+func TestAnalyzer_MaxRedirects(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer testServer.Close()
+
+	config := DefaultConfig()
+	config.MaxRedirects = 2
+
+	a, err := New(config)
+	assert.NoError(t, err)
+
+	response, err := a.Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL})
+	var limitErr *apperrors.LimitError
+	assert.True(t, errors.As(err, &limitErr), err)
+	assert.Equal(t, LimitRedirects, limitErr.Limit)
+	assert.ErrorContains(t, err, "redirected more than 2 times")
+	assert.Nil(t, response)
+}
+
+func TestNew_InvalidProxy(t *testing.T) {
+	config := DefaultConfig()
+	config.Proxy = "://proxy"
+
+	a, err := New(config)
+	assert.ErrorContains(t, err, "invalid proxy URL")
+	assert.Nil(t, a)
+}
@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sync"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultCrawlDepth = 2
+	DefaultCrawlPages = 10
+	// pages analyzed at once
+	crawlConcurrency = 4
+)
+
+// This will crawl the site starting from the request url.
+type CrawlRequest struct {
+	Url      string   `json:"url" binding:"required,url"`
+	MaxDepth *int     `json:"max_depth" binding:"omitempty,min=0,max=5"` // links followed from the seed page, DefaultCrawlDepth when unset, 0 only analyzes the seed page
+	MaxPages int      `json:"max_pages" binding:"min=0,max=100"`         // pages analyzed at most, DefaultCrawlPages when 0
+	Analyses []string `json:"analyses"`                                  // analyses to run on every page, all of them when empty
+	Exclude  []string `json:"exclude"`                                   // analyses to skip
+
+	RateLimit *RateLimitOptions `json:"rate_limit"` // link check limits of the crawl on top of the configured ones
+}
+
+type CrawlResponse struct {
+	Pages    []CrawledPage // Pages in the order they were discovered
+	Summary  CrawlSummary  // Stats aggregated over all pages
+	TimedOut bool          // true if the crawl stopped before it was done
+}
+
+type CrawledPage struct {
+	Url    string
+	Depth  int               // links followed from the seed page
+	Result *AnalyzerResponse // nil when the page couldn't be analyzed
+	Error  string
+}
+
+type CrawlSummary struct {
+	Pages             int            // Pages analyzed
+	FailedPages       int            // Pages which couldn't be analyzed
+	Headings          map[string]int // Headings count of all pages
+	InternalLinks     int
+	ExternalLinks     int
+	AccessibleLinks   int
+	InaccessibleLinks int
+	DisallowedLinks   int
+	LoginFormPages    []string // Pages with a login form
+}
+
+type crawlTarget struct {
+	url   string
+	depth int
+}
+
+// Crawl runs the request with the default configuration.
+func Crawl(ctx context.Context, request CrawlRequest) (*CrawlResponse, error) {
+	return defaultAnalyzer.Crawl(ctx, request)
+}
+
+// Crawl analyzes the seed page and then the internal pages it links to, breadth first.
+// The seed page failing fails the crawl, any other page only records its error.
+func (a *Analyzer) Crawl(ctx context.Context, request CrawlRequest) (*CrawlResponse, error) {
+	analyzerLogger := logger.FromContext(ctx)
+
+	maxDepth := DefaultCrawlDepth
+	if request.MaxDepth != nil {
+		maxDepth = *request.MaxDepth
+	}
+	maxPages := request.MaxPages
+	if maxPages == 0 {
+		maxPages = DefaultCrawlPages
+	}
+
+	seedUrl, err := url.Parse(request.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	// the limits are the crawl's, not of every page on its own
+	linkChecker := a.requestLinkChecker(request.RateLimit)
+
+	response := &CrawlResponse{Pages: make([]CrawledPage, 0)}
+	visited := map[string]bool{normalizeURL(request.Url): true}
+	queue := []crawlTarget{{url: request.Url}}
+
+	for len(queue) > 0 && len(response.Pages) < maxPages && ctx.Err() == nil {
+		batch := queue[:min(len(queue), maxPages-len(response.Pages), crawlConcurrency)]
+		queue = queue[len(batch):]
+
+		pages, roots := a.crawlBatch(ctx, request, batch, linkChecker)
+		for i, page := range pages {
+			if page.Depth == 0 && page.Result == nil {
+				return nil, roots[i].err
+			}
+
+			response.Pages = append(response.Pages, page)
+			if roots[i].root == nil || page.Depth >= maxDepth {
+				continue
+			}
+
+			pageUrl, _ := url.Parse(page.Url)
+			for _, link := range collectLinks(roots[i].root, pageUrl) {
+				linkUrl, err := url.Parse(link.LinkUrl)
+				if err != nil || (linkUrl.Scheme != "http" && linkUrl.Scheme != "https") {
+					continue
+				}
+				if getLinkType(linkUrl, seedUrl) != "internal" {
+					continue
+				}
+
+				key := normalizeURL(link.LinkUrl)
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+				queue = append(queue, crawlTarget{url: key, depth: page.Depth + 1})
+			}
+		}
+	}
+
+	response.TimedOut = ctx.Err() != nil
+	response.Summary = summarizeCrawl(response.Pages)
+	analyzerLogger.Info("Crawl finished",
+		slog.String("url", request.Url),
+		slog.Int("pages", response.Summary.Pages),
+		slog.Int("failed", response.Summary.FailedPages),
+	)
+
+	return response, nil
+}
+
+type crawledRoot struct {
+	root *html.Node
+	err  error
+}
+
+// crawlBatch analyzes the pages at the same time and returns them in the order of the batch.
+func (a *Analyzer) crawlBatch(ctx context.Context, request CrawlRequest, batch []crawlTarget, linkChecker *LinkChecker) ([]CrawledPage, []crawledRoot) {
+	pages := make([]CrawledPage, len(batch))
+	roots := make([]crawledRoot, len(batch))
+
+	var wg sync.WaitGroup
+	for i, target := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, root, err := a.analyze(ctx, AnalyzerRequest{
+				Url:      target.url,
+				Analyses: request.Analyses,
+				Exclude:  request.Exclude,
+			}, linkChecker)
+
+			pages[i] = CrawledPage{Url: target.url, Depth: target.depth, Result: result}
+			roots[i] = crawledRoot{root: root, err: err}
+			if err != nil {
+				pages[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return pages, roots
+}
+
+func summarizeCrawl(pages []CrawledPage) CrawlSummary {
+	summary := CrawlSummary{
+		Headings:       make(map[string]int),
+		LoginFormPages: make([]string, 0),
+	}
+
+	for _, page := range pages {
+		if page.Result == nil {
+			summary.FailedPages++
+			continue
+		}
+		summary.Pages++
+
+		for level, count := range page.Result.Headings {
+			summary.Headings[level] += count
+		}
+
+		if links := page.Result.LinkSummary; links != nil {
+			summary.InternalLinks += links.InternalLinks
+			summary.ExternalLinks += links.ExternalLinks
+			summary.AccessibleLinks += links.AccessibleLinks
+			summary.InaccessibleLinks += links.InaccessibleLinks
+			summary.DisallowedLinks += links.DisallowedLinks
+		}
+
+		if page.Result.HasLoginForm {
+			summary.LoginFormPages = append(summary.LoginFormPages, page.Url)
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func crawlServer() *httptest.Server {
+	pages := map[string]string{
+		"/":         `<h1>Home</h1><a href="/a">A</a><a href="/b#top">B</a><a href="https://external.invalid/">External</a><a href="mailto:me@example.com">Mail</a>`,
+		"/a":        `<h1>A</h1><h2>Sub</h2><a href="/a/1">A1</a><a href="/b">B again</a><a href="/missing">Missing</a>`,
+		"/b":        `<h1>B</h1><a href="/">Home</a><form><input type="password"><button type="submit">Login</button></form>`,
+		"/a/1":      `<h1>A1</h1><a href="/a/1/deep">Deep</a>`,
+		"/a/1/deep": `<h1>Deep</h1>`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := pages[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>%s</title></head><body>%s</body></html>", r.URL.Path, content)
+	}))
+}
+
+func TestCrawl(t *testing.T) {
+	testServer := crawlServer()
+	defer testServer.Close()
+
+	t.Run("Follows internal links up to the depth", func(t *testing.T) {
+		response, err := Crawl(context.Background(), CrawlRequest{Url: testServer.URL})
+		assert.NoError(t, err)
+		assert.False(t, response.TimedOut)
+
+		urls := make([]string, 0)
+		for _, page := range response.Pages {
+			urls = append(urls, page.Url)
+		}
+		assert.Equal(t, []string{
+			testServer.URL,
+			testServer.URL + "/a",
+			testServer.URL + "/b",
+			testServer.URL + "/a/1",
+			testServer.URL + "/missing",
+		}, urls)
+		assert.Equal(t, 2, response.Pages[3].Depth)
+
+		assert.Equal(t, "/a", response.Pages[1].Result.PageTitle)
+		assert.Nil(t, response.Pages[4].Result)
+		assert.Contains(t, response.Pages[4].Error, "Error on Accessing URL")
+
+		summary := response.Summary
+		assert.Equal(t, 4, summary.Pages)
+		assert.Equal(t, 1, summary.FailedPages)
+		assert.Equal(t, map[string]int{"h1": 4, "h2": 1}, summary.Headings)
+		assert.Equal(t, []string{testServer.URL + "/b"}, summary.LoginFormPages)
+		assert.Equal(t, 2, summary.ExternalLinks)
+		assert.Equal(t, 3, summary.InaccessibleLinks)
+	})
+
+	t.Run("Depth 0 only analyzes the seed page", func(t *testing.T) {
+		depth := 0
+		response, err := Crawl(context.Background(), CrawlRequest{Url: testServer.URL, MaxDepth: &depth})
+		assert.NoError(t, err)
+		assert.Len(t, response.Pages, 1)
+		assert.Equal(t, testServer.URL, response.Pages[0].Url)
+	})
+
+	t.Run("Stops at the page budget", func(t *testing.T) {
+		response, err := Crawl(context.Background(), CrawlRequest{
+			Url:      testServer.URL,
+			MaxPages: 2,
+			Analyses: []string{TitleAnalysis},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, response.Pages, 2)
+		assert.Equal(t, []string{TitleAnalysis}, response.Pages[1].Result.Analyses)
+	})
+
+	t.Run("Seed page failing fails the crawl", func(t *testing.T) {
+		response, err := Crawl(context.Background(), CrawlRequest{Url: testServer.URL + "/missing"})
+		assert.Error(t, err)
+		assert.Nil(t, response)
+	})
+}
+
+func TestCrawl_RateLimitIsShared(t *testing.T) {
+	linkServer, highest := concurrencyServer(20 * time.Millisecond)
+	defer linkServer.Close()
+
+	siteServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := ""
+		if r.URL.Path == "/" {
+			content = `<a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a>`
+		}
+		for i := range 3 {
+			content += fmt.Sprintf(`<a href="%s%s/%d">Link</a>`, linkServer.URL, r.URL.Path, i)
+		}
+		fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>%s</title></head><body>%s</body></html>", r.URL.Path, content)
+	}))
+	defer siteServer.Close()
+
+	depth := 1
+	response, err := Crawl(context.Background(), CrawlRequest{
+		Url:       siteServer.URL,
+		MaxDepth:  &depth,
+		Analyses:  []string{LinksAnalysis},
+		RateLimit: &RateLimitOptions{MaxConcurrentPerHost: 1},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, response.Pages, 5)
+
+	// the pages are analyzed at once, their link checks still go one at a time
+	assert.Equal(t, int32(1), highest.Load())
+}
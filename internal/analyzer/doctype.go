@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Document modes, the rendering mode browsers pick from the doctype
+const (
+	NoQuirksMode      = "no-quirks"
+	LimitedQuirksMode = "limited-quirks"
+	QuirksMode        = "quirks"
+)
+
+// versions by the public identifier of the doctype, lower cased and without its language
+var doctypeVersions = map[string]string{
+	"-//ietf//dtd html":                                  "HTML 2.0",
+	"-//ietf//dtd html 2.0":                              "HTML 2.0",
+	"-//w3c//dtd html 3.2":                               "HTML 3.2",
+	"-//w3c//dtd html 3.2 final":                         "HTML 3.2",
+	"-//w3c//dtd html 4.0":                               "HTML 4.0 Strict",
+	"-//w3c//dtd html 4.0 transitional":                  "HTML 4.0 Transitional",
+	"-//w3c//dtd html 4.0 frameset":                      "HTML 4.0 Frameset",
+	"-//w3c//dtd html 4.01":                              "HTML 4.01 Strict",
+	"-//w3c//dtd html 4.01 transitional":                 "HTML 4.01 Transitional",
+	"-//w3c//dtd html 4.01 frameset":                     "HTML 4.01 Frameset",
+	"-//w3c//dtd html 4.01+rdfa 1.1":                     "HTML+RDFa 1.1",
+	"-//w3c//dtd html 4.01+rdfa lite 1.1":                "HTML+RDFa Lite 1.1",
+	"-//w3c//dtd xhtml 1.0 strict":                       "XHTML 1.0 Strict",
+	"-//w3c//dtd xhtml 1.0 transitional":                 "XHTML 1.0 Transitional",
+	"-//w3c//dtd xhtml 1.0 frameset":                     "XHTML 1.0 Frameset",
+	"-//w3c//dtd xhtml 1.1":                              "XHTML 1.1",
+	"-//w3c//dtd xhtml basic 1.0":                        "XHTML Basic 1.0",
+	"-//w3c//dtd xhtml basic 1.1":                        "XHTML Basic 1.1",
+	"-//w3c//dtd xhtml+rdfa 1.0":                         "XHTML+RDFa 1.0",
+	"-//w3c//dtd xhtml+rdfa 1.1":                         "XHTML+RDFa 1.1",
+	"-//w3c//dtd xhtml 1.1 plus mathml 2.0":              "XHTML 1.1 plus MathML 2.0",
+	"-//w3c//dtd xhtml 1.1 plus mathml 2.0 plus svg 1.1": "XHTML 1.1 plus MathML 2.0 plus SVG 1.1",
+	"-//w3c//dtd svg 1.1":                                "SVG 1.1",
+}
+
+// public identifiers which put the document in quirks mode, from the HTML spec
+var quirksPublicPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+var quirksPublicIds = []string{"-//w3o//dtd w3 html strict 3.0//en//", "-/w3c/dtd html 4.0 transitional/en", "html"}
+
+const quirksSystemId = "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd"
+
+// these are quirks without a system identifier and limited quirks with one
+var html401LoosePrefixes = []string{"-//w3c//dtd html 4.01 frameset//", "-//w3c//dtd html 4.01 transitional//"}
+
+var limitedQuirksPrefixes = []string{"-//w3c//dtd xhtml 1.0 frameset//", "-//w3c//dtd xhtml 1.0 transitional//"}
+
+// doctype is the doctype of the parsed document.
+type doctype struct {
+	name     string
+	publicId string
+	systemId string
+}
+
+// findDoctype returns the doctype of the document, nil when it has none.
+func findDoctype(root *html.Node) *doctype {
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.DoctypeNode {
+			continue
+		}
+
+		d := &doctype{name: child.Data}
+		d.publicId, _ = getAttribute(child, "public")
+		d.systemId, _ = getAttribute(child, "system")
+		return d
+	}
+
+	return nil
+}
+
+// detectHTMLVersion tells the version declared by the doctype. XHTML served as XML doesn't need a doctype,
+// without one it's XHTML5.
+func detectHTMLVersion(d *doctype, xhtml bool) string {
+	if d == nil || d.name != "html" {
+		if d == nil && xhtml {
+			return "XHTML5"
+		}
+		return "Unknown"
+	}
+
+	if d.publicId == "" && (d.systemId == "" || d.systemId == "about:legacy-compat") {
+		if xhtml {
+			return "XHTML5"
+		}
+		return "HTML5"
+	}
+
+	// the language is the last part of the identifier, "EN" for all of these
+	publicId := strings.ToLower(d.publicId)
+	if i := strings.LastIndex(publicId, "//"); i > 0 {
+		publicId = publicId[:i]
+	}
+
+	if version, ok := doctypeVersions[publicId]; ok {
+		return version
+	}
+
+	return "Unknown"
+}
+
+// documentMode follows the doctype checks of the HTML parser, XML documents are never in quirks mode.
+func documentMode(d *doctype, xhtml bool) string {
+	if xhtml {
+		return NoQuirksMode
+	}
+
+	if d == nil || d.name != "html" {
+		return QuirksMode
+	}
+
+	publicId := strings.ToLower(d.publicId)
+	systemId := strings.ToLower(d.systemId)
+
+	startsWith := func(prefix string) bool { return strings.HasPrefix(publicId, prefix) }
+
+	if systemId == quirksSystemId || slices.Contains(quirksPublicIds, publicId) || slices.ContainsFunc(quirksPublicPrefixes, startsWith) {
+		return QuirksMode
+	}
+
+	if slices.ContainsFunc(html401LoosePrefixes, startsWith) {
+		if systemId == "" {
+			return QuirksMode
+		}
+		return LimitedQuirksMode
+	}
+
+	if slices.ContainsFunc(limitedQuirksPrefixes, startsWith) {
+		return LimitedQuirksMode
+	}
+
+	return NoQuirksMode
+}
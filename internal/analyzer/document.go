@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+)
+
+// This will analyze HTML which is at hand instead of fetching it, a saved snapshot for instance.
+type HTMLRequest struct {
+	BaseUrl  string   `json:"base_url" form:"base_url" binding:"omitempty,url"` // resolves the relative links, they're reported as unresolved and not checked when empty
+	Analyses []string `json:"analyses" form:"analyses"`                         // analyses to run, all of them when empty
+	Exclude  []string `json:"exclude" form:"exclude"`                           // analyses to skip
+
+	ContentType string `json:"content_type" form:"-"` // content type the HTML came with, its charset decodes the HTML
+
+	RateLimit *RateLimitOptions `json:"rate_limit" form:"-"` // link check limits of this analysis on top of the configured ones
+}
+
+// AnalyzeHTML runs the request with the default configuration.
+func AnalyzeHTML(ctx context.Context, body []byte, request HTMLRequest) (*AnalyzerResponse, error) {
+	return defaultAnalyzer.AnalyzeHTML(ctx, body, request)
+}
+
+// AnalyzeHTML runs the same extractors as Analyze against the given HTML.
+// The absolute links are still checked, the relative ones only when there's a base url to resolve them.
+func (a *Analyzer) AnalyzeHTML(ctx context.Context, body []byte, request HTMLRequest) (*AnalyzerResponse, error) {
+	analyzerLogger := logger.FromContext(ctx)
+
+	baseUrl := &url.URL{}
+	if request.BaseUrl != "" {
+		var err error
+		if baseUrl, err = url.Parse(request.BaseUrl); err != nil {
+			return nil, fmt.Errorf("invalid base URL syntax: %w", err)
+		}
+	}
+
+	extractors, err := selectExtractors(a.registry.Extractors(), request.Analyses, request.Exclude)
+	if err != nil {
+		analyzerLogger.Error("Invalid analyses", slog.Any("error", err))
+		return nil, err
+	}
+
+	header := http.Header{}
+	if request.ContentType != "" {
+		header.Set("Content-Type", request.ContentType)
+	}
+
+	result, _, err := a.analyzeBody(ctx, baseUrl, body, header, extractors, a.requestLinkChecker(request.RateLimit))
+	return result, err
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeHTML(t *testing.T) {
+	testServer := crawlServer()
+	defer testServer.Close()
+
+	body := []byte(`<!DOCTYPE html><html><head><title>Snapshot</title></head><body><h1>Snapshot</h1><a href="/a">A</a><a href="/missing">Missing</a></body></html>`)
+
+	t.Run("Relative links are resolved against the base url", func(t *testing.T) {
+		response, err := AnalyzeHTML(context.Background(), body, HTMLRequest{BaseUrl: testServer.URL})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Snapshot", response.PageTitle)
+		assert.Equal(t, "HTML5", response.HtmlVersion)
+		assert.Equal(t, map[string]int{"h1": 1}, response.Headings)
+		assert.Equal(t, testServer.URL+"/a", response.LinkSummary.Links[0].LinkUrl)
+		assert.Equal(t, 2, response.LinkSummary.InternalLinks)
+		assert.Equal(t, 1, response.LinkSummary.AccessibleLinks)
+		assert.Equal(t, 1, response.LinkSummary.InaccessibleLinks)
+	})
+
+	t.Run("Relative links aren't checked without a base url", func(t *testing.T) {
+		response, err := AnalyzeHTML(context.Background(), body, HTMLRequest{Analyses: []string{LinksAnalysis}})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "/a", response.LinkSummary.Links[0].LinkUrl)
+		assert.Equal(t, map[string]int{LinkUnresolved: 2}, response.LinkSummary.Categories)
+		assert.Equal(t, 2, response.LinkSummary.UncheckedLinks)
+		assert.Equal(t, 0, response.LinkSummary.InaccessibleLinks)
+	})
+
+	t.Run("Unknown analysis", func(t *testing.T) {
+		_, err := AnalyzeHTML(context.Background(), body, HTMLRequest{Analyses: []string{"unknown"}})
+		assert.ErrorIs(t, err, ErrUnknownAnalysis)
+	})
+}
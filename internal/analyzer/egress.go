@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrBlockedByPolicy = errors.New("blocked by the egress policy")
+
+// EgressPolicy limits where the analyzer connects to, so a submitted url can't reach the internal network.
+// The hosts and ports are checked for every request, redirects included, and the addresses when they're dialed,
+// after the DNS resolution. Behind a proxy the address of the proxy is the one which is dialed.
+type EgressPolicy struct {
+	AllowPrivate bool     // allow private, loopback, link-local and other non public addresses
+	AllowHosts   []string // only these hosts are allowed when set, "*.example.com" matches the subdomains
+	DenyHosts    []string // these hosts are never allowed, same patterns as AllowHosts
+	AllowPorts   []int    // only these ports are allowed when set
+}
+
+// EgressError tells why a request was blocked.
+type EgressError struct {
+	Target string // host or address which was blocked
+	Reason string
+}
+
+func (e *EgressError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Target, ErrBlockedByPolicy, e.Reason)
+}
+
+func (e *EgressError) Unwrap() error {
+	return ErrBlockedByPolicy
+}
+
+// non public ranges which the net/netip helpers don't cover
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast included
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, can point to any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("fec0::/10"),       // deprecated site local
+	netip.MustParsePrefix("2002::/16"),       // 6to4, can embed a private IPv4 address
+	netip.MustParsePrefix("2001::/32"),       // Teredo, same as above
+	netip.MustParsePrefix("100::/64"),        // discard only
+	netip.MustParsePrefix("::ffff:0:0:0/96"), // IPv4 translated
+}
+
+// checkURL checks the host and the port of the request url.
+func (p *EgressPolicy) checkURL(scheme, host, port string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if matchHosts(p.DenyHosts, host) {
+		return &EgressError{Target: host, Reason: "host is denied"}
+	}
+
+	if len(p.AllowHosts) > 0 && !matchHosts(p.AllowHosts, host) {
+		return &EgressError{Target: host, Reason: "host isn't allowed"}
+	}
+
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[scheme]
+	}
+	number, _ := strconv.Atoi(port)
+	if len(p.AllowPorts) > 0 && !slices.Contains(p.AllowPorts, number) {
+		return &EgressError{Target: net.JoinHostPort(host, port), Reason: "port isn't allowed"}
+	}
+
+	return nil
+}
+
+// checkAddress checks the address which is about to be dialed.
+func (p *EgressPolicy) checkAddress(address string) error {
+	if p.AllowPrivate {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return &EgressError{Target: address, Reason: "address can't be verified"}
+	}
+
+	if !isPublicAddr(addrPort.Addr()) {
+		return &EgressError{Target: address, Reason: "address isn't public"}
+	}
+
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchHosts matches the host exactly or, for "*.example.com", any of its subdomains.
+func matchHosts(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+
+		if host == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// egressDialer dials the resolved addresses only once the policy allows them.
+func egressDialer(policy *EgressPolicy) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return policy.checkAddress(address)
+		},
+	}
+}
+
+// egressTransport checks the url of every request, the ones of the redirects included.
+type egressTransport struct {
+	policy *EgressPolicy
+	next   http.RoundTripper
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.checkURL(req.URL.Scheme, req.URL.Hostname(), req.URL.Port()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.public, isPublicAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestEgressPolicy_CheckURL(t *testing.T) {
+	policy := &EgressPolicy{
+		AllowHosts: []string{"example.com", "*.example.org"},
+		DenyHosts:  []string{"private.example.org"},
+		AllowPorts: []int{80, 443},
+	}
+
+	tests := []struct {
+		url    string
+		reason string
+	}{
+		{"http://example.com/", ""},
+		{"https://EXAMPLE.com./page", ""},
+		{"https://www.example.org/", ""},
+		{"http://example.org/", "host isn't allowed"},
+		{"http://private.example.org/", "host is denied"},
+		{"http://example.net/", "host isn't allowed"},
+		{"http://example.com:8080/", "port isn't allowed"},
+		{"https://example.com:443/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			err := policy.checkURL(u.Scheme, u.Hostname(), u.Port())
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var egressErr *EgressError
+			assert.True(t, errors.As(err, &egressErr))
+			assert.Equal(t, tt.reason, egressErr.Reason)
+			assert.ErrorIs(t, err, ErrBlockedByPolicy)
+		})
+	}
+}
+
+func TestAnalyzer_Egress(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "http://denied.test/", http.StatusFound)
+		case "/about":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><title>Egress</title></head><body><a href="/about">About</a><a href="http://127.0.0.1:1/">Closed</a></body></html>`)
+		}
+	}))
+	defer testServer.Close()
+
+	newAnalyzer := func(policy *EgressPolicy) *Analyzer {
+		config := DefaultConfig()
+		config.LinkCacheSize = 0
+		config.Egress = policy
+
+		a, err := New(config)
+		assert.NoError(t, err)
+		return a
+	}
+
+	t.Run("Private addresses are blocked after the resolution", func(t *testing.T) {
+		a := newAnalyzer(&EgressPolicy{})
+
+		// localhost resolves to the loopback address, the name alone doesn't give it away
+		localUrl := "http://localhost:" + testServer.URL[len("http://127.0.0.1:"):]
+		for _, pageUrl := range []string{testServer.URL, localUrl} {
+			_, err := a.Analyze(context.Background(), AnalyzerRequest{Url: pageUrl})
+			assert.ErrorIs(t, err, ErrBlockedByPolicy)
+
+			var egressErr *EgressError
+			assert.True(t, errors.As(err, &egressErr))
+			assert.Equal(t, "address isn't public", egressErr.Reason)
+		}
+	})
+
+	t.Run("Redirects are checked", func(t *testing.T) {
+		a := newAnalyzer(&EgressPolicy{AllowPrivate: true, DenyHosts: []string{"denied.test"}})
+
+		_, err := a.Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL + "/redirect"})
+		assert.ErrorIs(t, err, ErrBlockedByPolicy)
+		assert.ErrorContains(t, err, "denied.test")
+	})
+
+	t.Run("Blocked links are reported", func(t *testing.T) {
+		u, _ := url.Parse(testServer.URL)
+		port, _ := strconv.Atoi(u.Port())
+		a := newAnalyzer(&EgressPolicy{AllowPrivate: true, AllowPorts: []int{port}})
+
+		response, err := a.Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL})
+		assert.NoError(t, err)
+		assert.Equal(t, "Egress", response.PageTitle)
+		assert.Equal(t, map[string]int{LinkOK: 1, LinkBlocked: 1}, response.LinkSummary.Categories)
+		assert.Contains(t, response.LinkSummary.Links[1].Status.Error, "port isn't allowed")
+	})
+}
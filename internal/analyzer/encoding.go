@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodeBody transcodes the body to UTF-8 and returns the name of the encoding it was in.
+// The encoding is taken from the BOM, the charset of the content type and the <meta> tags of the page,
+// in that order. A body which declares none of them is taken as UTF-8 when it's valid UTF-8, windows-1252 otherwise.
+func decodeBody(body []byte, contentType string) ([]byte, string, error) {
+	encoding, name, certain := charset.DetermineEncoding(body, contentType)
+	// the guess only looks at the first 1024 bytes, the UTF-8 text may come after them
+	if !certain && !declaresCharset(body) && utf8.Valid(body) {
+		name = "utf-8"
+	}
+
+	if name != "utf-8" {
+		var err error
+		if body, err = encoding.NewDecoder().Bytes(body); err != nil {
+			return nil, name, err
+		}
+	}
+
+	// the UTF-16 decoders keep the BOM, it comes out as the UTF-8 one
+	return bytes.TrimPrefix(body, utf8BOM), name, nil
+}
+
+// declaresCharset tells whether a <meta> tag within the first 1024 bytes declares a known charset,
+// the same bytes DetermineEncoding looks at.
+func declaresCharset(body []byte) bool {
+	tokenizer := html.NewTokenizer(bytes.NewReader(body[:min(len(body), 1024)]))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "meta" {
+				continue
+			}
+
+			var label, content string
+			httpEquiv := false
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				switch string(key) {
+				case "charset":
+					label = string(value)
+				case "http-equiv":
+					httpEquiv = strings.EqualFold(string(value), "content-type")
+				case "content":
+					content = string(value)
+				}
+			}
+
+			if label == "" && httpEquiv {
+				if _, params, err := mime.ParseMediaType(content); err == nil {
+					label = params["charset"]
+				}
+			}
+			if encoding, _ := charset.Lookup(label); encoding != nil {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, content string) []byte {
+	encoded, err := enc.NewEncoder().Bytes([]byte(content))
+	assert.NoError(t, err)
+	return encoded
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		encoding    string
+		expected    string
+	}{
+		{
+			name:        "Charset of the content type",
+			body:        encode(t, japanese.ShiftJIS, "<title>日本語</title>"),
+			contentType: "text/html; charset=Shift_JIS",
+			encoding:    "shift_jis",
+			expected:    "<title>日本語</title>",
+		},
+		{
+			name:     "Meta charset",
+			body:     encode(t, charmap.Windows1252, `<meta charset="windows-1252"><title>Café €</title>`),
+			encoding: "windows-1252",
+			expected: `<meta charset="windows-1252"><title>Café €</title>`,
+		},
+		{
+			name:     "Meta http-equiv",
+			body:     encode(t, charmap.ISO8859_1, `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><title>Ærø</title>`),
+			encoding: "windows-1252", // browsers treat latin-1 as windows-1252
+			expected: `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><title>Ærø</title>`,
+		},
+		{
+			name:        "BOM wins over the content type",
+			body:        encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "<title>BOM</title>"),
+			contentType: "text/html; charset=iso-8859-1",
+			encoding:    "utf-16le",
+			expected:    "<title>BOM</title>",
+		},
+		{
+			name:     "UTF-8 BOM is dropped",
+			body:     append([]byte{0xEF, 0xBB, 0xBF}, "<title>ü</title>"...),
+			encoding: "utf-8",
+			expected: "<title>ü</title>",
+		},
+		{
+			name:     "Meta charset wins over bytes which are valid UTF-8",
+			body:     []byte(`<meta charset="windows-1252"><title>` + "\xc3\xa9" + `</title>`),
+			encoding: "windows-1252",
+			expected: `<meta charset="windows-1252"><title>Ã©</title>`,
+		},
+		{
+			name:     "Meta http-equiv of an ASCII page",
+			body:     []byte(`<meta http-equiv="content-type" content="text/html; charset=iso-8859-1"><title>Plain</title>`),
+			encoding: "windows-1252",
+			expected: `<meta http-equiv="content-type" content="text/html; charset=iso-8859-1"><title>Plain</title>`,
+		},
+		{
+			name:     "Undeclared UTF-8 after the first 1024 bytes",
+			body:     []byte("<title>Cafe</title>" + strings.Repeat(" ", 1024) + "<h1>Café</h1>"),
+			encoding: "utf-8",
+			expected: "<title>Cafe</title>" + strings.Repeat(" ", 1024) + "<h1>Café</h1>",
+		},
+		{
+			name:     "Undeclared ASCII is UTF-8",
+			body:     []byte("<title>Plain</title>"),
+			encoding: "utf-8",
+			expected: "<title>Plain</title>",
+		},
+		{
+			name:     "Undeclared encoding defaults to windows-1252",
+			body:     []byte("<title>Caf\xe9</title>"),
+			encoding: "windows-1252",
+			expected: "<title>Café</title>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, name, err := decodeBody(tt.body, tt.contentType)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.encoding, name)
+			assert.Equal(t, tt.expected, string(decoded))
+		})
+	}
+}
+
+func TestAnalyzer_Encoding(t *testing.T) {
+	page := encode(t, japanese.ShiftJIS, `<!DOCTYPE html><html><head><title>ようこそ</title></head><body><h1>見出し</h1></body></html>`)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		w.Write(page)
+	}))
+	defer testServer.Close()
+
+	response, err := Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL, Analyses: []string{TitleAnalysis}})
+	assert.NoError(t, err)
+	assert.Equal(t, "shift_jis", response.Encoding)
+	assert.Equal(t, "ようこそ", response.PageTitle)
+}
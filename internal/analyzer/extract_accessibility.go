@@ -0,0 +1,243 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const AccessibilityAnalysis = "accessibility"
+
+// Accessibility rules
+const (
+	RuleImageAlt    = "image-alt"
+	RuleLabel       = "label"
+	RuleHtmlLang    = "html-lang"
+	RuleLinkName    = "link-name"
+	RuleButtonName  = "button-name"
+	RuleDuplicateId = "duplicate-id"
+	RuleAriaRole    = "aria-role"
+	RuleAriaAttr    = "aria-attr"
+	RuleTabindex    = "tabindex"
+)
+
+// AccessibilityResult is the outcome of the accessibility checks of the page.
+type AccessibilityResult struct {
+	Score    int // percentage of the checks which passed, 100 when nothing was checked
+	Checks   int // checks made, one per rule which applies to an element
+	Findings []Finding
+}
+
+// WAI-ARIA 1.2 roles, the doc- and graphics- ones are checked by their prefix
+var ariaRoles = []string{
+	"alert", "alertdialog", "application", "article", "banner", "blockquote", "button", "caption", "cell", "checkbox",
+	"code", "columnheader", "combobox", "complementary", "contentinfo", "definition", "deletion", "dialog", "directory",
+	"document", "emphasis", "feed", "figure", "form", "generic", "grid", "gridcell", "group", "heading", "img",
+	"insertion", "link", "list", "listbox", "listitem", "log", "main", "marquee", "math", "menu", "menubar", "menuitem",
+	"menuitemcheckbox", "menuitemradio", "meter", "navigation", "none", "note", "option", "paragraph", "presentation",
+	"progressbar", "radio", "radiogroup", "region", "row", "rowgroup", "rowheader", "scrollbar", "search", "searchbox",
+	"separator", "slider", "spinbutton", "status", "strong", "subscript", "superscript", "switch", "tab", "table",
+	"tablist", "tabpanel", "term", "textbox", "time", "timer", "toolbar", "tooltip", "tree", "treegrid", "treeitem",
+}
+
+// WAI-ARIA 1.2 states and properties
+var ariaAttributes = []string{
+	"aria-activedescendant", "aria-atomic", "aria-autocomplete", "aria-braillelabel", "aria-brailleroledescription",
+	"aria-busy", "aria-checked", "aria-colcount", "aria-colindex", "aria-colindextext", "aria-colspan", "aria-controls",
+	"aria-current", "aria-describedby", "aria-description", "aria-details", "aria-disabled", "aria-dropeffect",
+	"aria-errormessage", "aria-expanded", "aria-flowto", "aria-grabbed", "aria-haspopup", "aria-hidden", "aria-invalid",
+	"aria-keyshortcuts", "aria-label", "aria-labelledby", "aria-level", "aria-live", "aria-modal", "aria-multiline",
+	"aria-multiselectable", "aria-orientation", "aria-owns", "aria-placeholder", "aria-posinset", "aria-pressed",
+	"aria-readonly", "aria-relevant", "aria-required", "aria-roledescription", "aria-rowcount", "aria-rowindex",
+	"aria-rowindextext", "aria-rowspan", "aria-selected", "aria-setsize", "aria-sort", "aria-valuemax", "aria-valuemin",
+	"aria-valuenow", "aria-valuetext",
+}
+
+// input types which need no label, they're either not shown or labelled by their value
+var unlabelledInputs = []string{"hidden", "submit", "reset", "button", "image"}
+
+func init() {
+	MustRegister(NewExtractor(AccessibilityAnalysis, ExtractAccessibility))
+}
+
+func ExtractAccessibility(ctx context.Context, root *html.Node, page *Page) (AccessibilityResult, error) {
+	a := &audit{findings: make([]Finding, 0)}
+
+	// labels may come after the fields they label
+	labelled := make(map[string]bool)
+	walkElements(root, func(node *html.Node) {
+		if node.Data == "label" {
+			if id, ok := getAttribute(node, "for"); ok {
+				labelled[id] = true
+			}
+		}
+	})
+
+	ids := make(map[string]bool)
+	walkElements(root, func(node *html.Node) {
+		a.checkAttributes(node, ids)
+
+		// the tag rules are about the HTML elements, not the svg or math ones
+		if node.Namespace != "" {
+			return
+		}
+
+		switch node.Data {
+		case "html":
+			lang, _ := getAttribute(node, "lang")
+			a.check(strings.TrimSpace(lang) != "", node, RuleHtmlLang, SeverityError, "html element has no lang attribute")
+		case "img":
+			a.checkImageAlt(node)
+		case "a":
+			if _, ok := getAttribute(node, "href"); ok {
+				a.check(accessibleName(node) != "", node, RuleLinkName, SeverityError, "link has no text")
+			}
+		case "button":
+			a.check(accessibleName(node) != "", node, RuleButtonName, SeverityError, "button has no text")
+		case "input", "select", "textarea":
+			a.checkField(node, labelled)
+		}
+
+		if role, _ := getAttribute(node, "role"); strings.EqualFold(strings.TrimSpace(role), "button") && node.Data != "button" {
+			a.check(accessibleName(node) != "", node, RuleButtonName, SeverityError, "button has no text")
+		}
+	})
+
+	return AccessibilityResult{Score: a.score(), Checks: a.checks, Findings: a.findings}, nil
+}
+
+func (r AccessibilityResult) Apply(response *AnalyzerResponse) {
+	response.Accessibility = &r
+}
+
+// audit counts the checks and keeps a finding for the ones which fail.
+type audit struct {
+	checks   int
+	findings []Finding
+}
+
+func (a *audit) check(ok bool, node *html.Node, rule, severity, message string) {
+	a.checks++
+	if !ok {
+		a.findings = append(a.findings, Finding{Rule: rule, Severity: severity, Message: message, Path: cssPath(node)})
+	}
+}
+
+func (a *audit) score() int {
+	if a.checks == 0 {
+		return 100
+	}
+	return int(math.Round(100 * float64(a.checks-len(a.findings)) / float64(a.checks)))
+}
+
+// checkAttributes checks the attributes any element may have, ids, roles, ARIA and tabindex.
+func (a *audit) checkAttributes(node *html.Node, ids map[string]bool) {
+	if id, ok := getAttribute(node, "id"); ok && id != "" {
+		a.check(!ids[id], node, RuleDuplicateId, SeverityWarning, fmt.Sprintf("id %q is used more than once", id))
+		ids[id] = true
+	}
+
+	if role, ok := getAttribute(node, "role"); ok {
+		invalid := make([]string, 0)
+		for _, token := range strings.Fields(strings.ToLower(role)) {
+			if !slices.Contains(ariaRoles, token) && !strings.HasPrefix(token, "doc-") && !strings.HasPrefix(token, "graphics-") {
+				invalid = append(invalid, token)
+			}
+		}
+		a.check(len(invalid) == 0, node, RuleAriaRole, SeverityError, fmt.Sprintf("role %s isn't a valid ARIA role", strings.Join(invalid, ", ")))
+	}
+
+	invalid := make([]string, 0)
+	checked := false
+	for _, attr := range node.Attr {
+		if strings.HasPrefix(attr.Key, "aria-") {
+			checked = true
+			if !slices.Contains(ariaAttributes, attr.Key) {
+				invalid = append(invalid, attr.Key)
+			}
+		}
+	}
+	if checked {
+		a.check(len(invalid) == 0, node, RuleAriaAttr, SeverityError, fmt.Sprintf("%s isn't a valid ARIA attribute", strings.Join(invalid, ", ")))
+	}
+
+	if tabindex, ok := getAttribute(node, "tabindex"); ok {
+		value, err := strconv.Atoi(strings.TrimSpace(tabindex))
+		a.check(err != nil || value <= 0, node, RuleTabindex, SeverityWarning,
+			fmt.Sprintf("tabindex %s changes the focus order", tabindex))
+	}
+}
+
+// checkImageAlt accepts an empty alt, it marks the image as decorative.
+func (a *audit) checkImageAlt(node *html.Node) {
+	role, _ := getAttribute(node, "role")
+	if role = strings.ToLower(strings.TrimSpace(role)); role == "presentation" || role == "none" {
+		return
+	}
+
+	_, hasAlt := getAttribute(node, "alt")
+	a.check(hasAlt || ariaLabel(node) != "", node, RuleImageAlt, SeverityError, "image has no alt attribute")
+}
+
+func (a *audit) checkField(node *html.Node, labelled map[string]bool) {
+	fieldType, _ := getAttribute(node, "type")
+	if node.Data == "input" && slices.Contains(unlabelledInputs, strings.ToLower(fieldType)) {
+		// an input button without a value has no text, submit and reset have a default one
+		if strings.EqualFold(fieldType, "button") {
+			value, _ := getAttribute(node, "value")
+			a.check(strings.TrimSpace(value) != "" || ariaLabel(node) != "", node, RuleButtonName, SeverityError, "button has no text")
+		}
+		if strings.EqualFold(fieldType, "image") {
+			alt, _ := getAttribute(node, "alt")
+			a.check(strings.TrimSpace(alt) != "" || ariaLabel(node) != "", node, RuleImageAlt, SeverityError, "image button has no alt attribute")
+		}
+		return
+	}
+
+	id, _ := getAttribute(node, "id")
+	title, _ := getAttribute(node, "title")
+	ok := (id != "" && labelled[id]) || hasAncestor(node, "label") || ariaLabel(node) != "" || strings.TrimSpace(title) != ""
+	a.check(ok, node, RuleLabel, SeverityError, fmt.Sprintf("%s has no label", node.Data))
+}
+
+// accessibleName is roughly the name screen readers announce for the element.
+func accessibleName(node *html.Node) string {
+	if label := ariaLabel(node); label != "" {
+		return label
+	}
+
+	if text := getText(node); text != "" {
+		return text
+	}
+
+	if alts := imageAlts(node); alts != "" {
+		return alts
+	}
+
+	title, _ := getAttribute(node, "title")
+	return strings.TrimSpace(title)
+}
+
+// ariaLabel is the aria-label, or the ids of aria-labelledby as they name the element too.
+func ariaLabel(node *html.Node) string {
+	for _, key := range []string{"aria-label", "aria-labelledby"} {
+		if value, _ := getAttribute(node, key); strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func hasAncestor(node *html.Node, tag string) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent.Type == html.ElementNode && parent.Data == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractAccessibility(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_accessibility.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	result, err := ExtractAccessibility(context.Background(), root, &Page{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Finding{
+		{Rule: RuleHtmlLang, Severity: SeverityError, Message: "html element has no lang attribute", Path: "html"},
+		{Rule: RuleLinkName, Severity: SeverityError, Message: "link has no text", Path: "html > body > nav > ul > li:nth-of-type(2) > a"},
+		{Rule: RuleImageAlt, Severity: SeverityError, Message: "image has no alt attribute", Path: "html > body > main#main > img:nth-of-type(1)"},
+		{Rule: RuleLabel, Severity: SeverityError, Message: "input has no label", Path: "html > body > main#main > form > input#email:nth-of-type(2)"},
+		{Rule: RuleDuplicateId, Severity: SeverityWarning, Message: `id "main" is used more than once`, Path: "html > body > main#main > form > select#main"},
+		{Rule: RuleLabel, Severity: SeverityError, Message: "select has no label", Path: "html > body > main#main > form > select#main"},
+		{Rule: RuleButtonName, Severity: SeverityError, Message: "button has no text", Path: "html > body > main#main > form > input:nth-of-type(4)"},
+		{Rule: RuleTabindex, Severity: SeverityWarning, Message: "tabindex 2 changes the focus order", Path: "html > body > main#main > form > button:nth-of-type(1)"},
+		{Rule: RuleButtonName, Severity: SeverityError, Message: "button has no text", Path: "html > body > main#main > form > button:nth-of-type(1)"},
+		{Rule: RuleAriaAttr, Severity: SeverityError, Message: "aria-labeled isn't a valid ARIA attribute", Path: "html > body > main#main > form > button:nth-of-type(2)"},
+		{Rule: RuleAriaRole, Severity: SeverityError, Message: "role buton isn't a valid ARIA role", Path: "html > body > main#main > div"},
+	}, result.Findings)
+
+	assert.Equal(t, 26, result.Checks)
+	assert.Equal(t, 58, result.Score)
+}
+
+func TestExtractAccessibility_Score(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<html lang="en"><body><a href="/">Home</a><img src="a.png" alt="A"></body></html>`))
+	assert.NoError(t, err)
+
+	result, err := ExtractAccessibility(context.Background(), root, &Page{})
+	assert.NoError(t, err)
+	assert.Empty(t, result.Findings)
+	assert.Equal(t, 3, result.Checks)
+	assert.Equal(t, 100, result.Score)
+}
+
+func TestCssPath(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul id="menu"><li>One</li><li><a href="/">Two</a></li></ul><p>Text</p>`))
+	assert.NoError(t, err)
+
+	nodes := make([]html.Node, 0)
+	getMatchingNodes(root, &nodes, "a")
+	assert.Equal(t, "html > body > ul#menu > li:nth-of-type(2) > a", cssPath(&nodes[0]))
+}
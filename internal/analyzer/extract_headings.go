@@ -1,45 +1,185 @@
 package analyzer
 
 import (
-	"log/slog"
-	"sync"
-	"time"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
 	"golang.org/x/net/html"
 )
 
-func ExtractHeadings(root *html.Node, wg *sync.WaitGroup, resultChan chan AnalyzerResponse) {
-	start := time.Now()
-	status := "Success"
-	functionName := "ExtractHeadings"
-	defer wg.Done()
+const HeadingsAnalysis = "headings"
 
-	headingCounts := headingsMap(root)
+// Heading rules
+const (
+	RuleMissingH1     = "missing-h1"
+	RuleMultipleH1    = "multiple-h1"
+	RuleSkippedLevel  = "skipped-heading-level"
+	RuleEmptyHeading  = "empty-heading"
+	RuleHiddenHeading = "hidden-heading"
+)
+
+// HeadingsResult is the count of headings by their level, the outline they make and the issues with it.
+type HeadingsResult struct {
+	Counts   map[string]int
+	Outline  []Heading
+	Findings []Finding
+}
 
-	// Send the result to the channel
-	resultChan <- AnalyzerResponse{Headings: headingCounts}
-	duration := time.Since(start).Nanoseconds()
-	analyzerLogger.Info("Function Executed",
-		slog.String("function", functionName),
-		slog.Int64("duration", duration),
-	)
+type Heading struct {
+	Level  int    // 1 for h1, aria-level for role="heading"
+	Tag    string // element of the heading, "div" for a role="heading" div for instance
+	Text   string
+	Depth  int  // nesting in the outline, 0 for the top level
+	Hidden bool // hidden from everyone or from screen readers only, by the heading or an element around it
+}
 
-	observability.
-		DurationMetrics.
-		WithLabelValues(functionName, status).
-		Observe(float64(duration))
+func init() {
+	MustRegister(NewExtractor(HeadingsAnalysis, ExtractHeadings))
 }
 
-func headingsMap(node *html.Node) map[string]int {
-	// Check if the node is an element node and is a heading tag (h1 to h6)
-	headingCounts := make(map[string]int)
-	nodes := make([]html.Node, 0)
-	getMatchingNodes(node, &nodes, "h1", "h2", "h3", "h4", "h5", "h6")
+func ExtractHeadings(ctx context.Context, root *html.Node, page *Page) (HeadingsResult, error) {
+	outline := headingOutline(root)
+
+	counts := make(map[string]int)
+	for _, heading := range outline {
+		counts[fmt.Sprintf("h%d", heading.Level)]++
+	}
+
+	return HeadingsResult{Counts: counts, Outline: outline, Findings: headingFindings(outline)}, nil
+}
+
+func (h HeadingsResult) Apply(response *AnalyzerResponse) {
+	if len(h.Counts) > 0 {
+		response.Headings = h.Counts
+	}
+	response.HeadingOutline = h.Outline
+	response.HeadingFindings = h.Findings
+}
+
+// headingOutline lists the headings in document order, nested under the closest heading of a lower level.
+func headingOutline(root *html.Node) []Heading {
+	outline := make([]Heading, 0)
+	open := make([]int, 0) // levels of the headings the next one may nest under
+
+	var walk func(node *html.Node, hidden bool)
+	walk = func(node *html.Node, hidden bool) {
+		if node.Type == html.ElementNode {
+			hidden = hidden || isHidden(node)
+
+			if level, ok := headingLevel(node); ok {
+				for len(open) > 0 && open[len(open)-1] >= level {
+					open = open[:len(open)-1]
+				}
+
+				outline = append(outline, Heading{
+					Level:  level,
+					Tag:    node.Data,
+					Text:   headingText(node),
+					Depth:  len(open),
+					Hidden: hidden,
+				})
+				open = append(open, level)
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, hidden)
+		}
+	}
+	walk(root, false)
+
+	return outline
+}
+
+// headingLevel gives the level of h1 to h6 and of role="heading", aria-level wins over the tag.
+func headingLevel(node *html.Node) (int, bool) {
+	level := 0
+	if len(node.Data) == 2 && node.Data[0] == 'h' && node.Data[1] >= '1' && node.Data[1] <= '6' {
+		level = int(node.Data[1] - '0')
+	} else if role, _ := getAttribute(node, "role"); strings.EqualFold(strings.TrimSpace(role), "heading") {
+		// aria-level defaults to 2
+		level = 2
+	} else {
+		return 0, false
+	}
+
+	if ariaLevel, ok := getAttribute(node, "aria-level"); ok {
+		if value, err := strconv.Atoi(strings.TrimSpace(ariaLevel)); err == nil && value > 0 {
+			level = value
+		}
+	}
+
+	return level, true
+}
+
+// headingText is the text of the heading, its aria-label or the alt of its images when it has no text.
+func headingText(node *html.Node) string {
+	if text := getText(node); text != "" {
+		return text
+	}
+
+	if label, _ := getAttribute(node, "aria-label"); strings.TrimSpace(label) != "" {
+		return strings.TrimSpace(label)
+	}
+
+	return imageAlts(node)
+}
+
+func isHidden(node *html.Node) bool {
+	if _, ok := getAttribute(node, "hidden"); ok {
+		return true
+	}
+
+	ariaHidden, _ := getAttribute(node, "aria-hidden")
+	return strings.EqualFold(strings.TrimSpace(ariaHidden), "true")
+}
+
+// headingFindings checks the structure of the outline. The levels are checked on the headings which are shown only,
+// the hidden ones are reported on their own.
+func headingFindings(outline []Heading) []Finding {
+	findings := make([]Finding, 0)
+
+	var h1s, previous int
+	for _, heading := range outline {
+		if heading.Text == "" {
+			findings = append(findings, Finding{
+				Rule:     RuleEmptyHeading,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s heading has no text", heading.Tag),
+			})
+		}
+
+		if heading.Hidden {
+			findings = append(findings, Finding{
+				Rule:     RuleHiddenHeading,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("h%d %q is hidden", heading.Level, heading.Text),
+			})
+			continue
+		}
+
+		if heading.Level == 1 {
+			h1s++
+		}
+
+		if previous > 0 && heading.Level > previous+1 {
+			findings = append(findings, Finding{
+				Rule:     RuleSkippedLevel,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("h%d is followed by h%d %q", previous, heading.Level, heading.Text),
+			})
+		}
+		previous = heading.Level
+	}
 
-	for _, value := range nodes {
-		headingCounts[value.Data]++
+	switch {
+	case h1s == 0:
+		findings = append(findings, Finding{Rule: RuleMissingH1, Severity: SeverityError, Message: "page has no h1"})
+	case h1s > 1:
+		findings = append(findings, Finding{Rule: RuleMultipleH1, Severity: SeverityWarning, Message: fmt.Sprintf("page has %d h1 headings", h1s)})
 	}
 
-	return headingCounts
+	return findings
 }
@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractHeadings_Outline(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_heading_outline.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	result, err := ExtractHeadings(context.Background(), root, &Page{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Heading{
+		{Level: 1, Tag: "h1", Text: "Guide", Depth: 0},
+		{Level: 2, Tag: "h2", Text: "Install", Depth: 1},
+		{Level: 4, Tag: "h4", Text: "From source", Depth: 2},
+		{Level: 3, Tag: "div", Text: "Binaries", Depth: 2},
+		{Level: 2, Tag: "h2", Text: "Usage", Depth: 1},
+		{Level: 3, Tag: "h3", Text: "", Depth: 2},
+		{Level: 2, Tag: "h2", Text: "Menu", Depth: 1, Hidden: true},
+		{Level: 2, Tag: "h1", Text: "Appendix", Depth: 1},
+		{Level: 1, Tag: "h1", Text: "Old guide", Depth: 0, Hidden: true},
+	}, result.Outline)
+
+	assert.Equal(t, map[string]int{"h1": 2, "h2": 4, "h3": 2, "h4": 1}, result.Counts)
+
+	assert.Equal(t, []Finding{
+		{Rule: RuleSkippedLevel, Severity: SeverityWarning, Message: `h2 is followed by h4 "From source"`},
+		{Rule: RuleEmptyHeading, Severity: SeverityError, Message: "h3 heading has no text"},
+		{Rule: RuleHiddenHeading, Severity: SeverityWarning, Message: `h2 "Menu" is hidden`},
+		{Rule: RuleHiddenHeading, Severity: SeverityWarning, Message: `h1 "Old guide" is hidden`},
+	}, result.Findings)
+}
+
+func TestExtractHeadings_H1(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		findings []Finding
+	}{
+		{
+			name:     "Single h1",
+			content:  "<h1>Title</h1><h2>Section</h2>",
+			findings: []Finding{},
+		},
+		{
+			name:     "Missing h1",
+			content:  "<h2>Section</h2><h3>Sub section</h3>",
+			findings: []Finding{{Rule: RuleMissingH1, Severity: SeverityError, Message: "page has no h1"}},
+		},
+		{
+			name:     "Only a hidden h1",
+			content:  `<h1 hidden>Title</h1><h2>Section</h2>`,
+			findings: []Finding{{Rule: RuleHiddenHeading, Severity: SeverityWarning, Message: `h1 "Title" is hidden`}, {Rule: RuleMissingH1, Severity: SeverityError, Message: "page has no h1"}},
+		},
+		{
+			name:     "Multiple h1",
+			content:  `<h1>Title</h1><div role="heading" aria-level="1">Another title</div>`,
+			findings: []Finding{{Rule: RuleMultipleH1, Severity: SeverityWarning, Message: "page has 2 h1 headings"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.content))
+			assert.NoError(t, err)
+
+			result, err := ExtractHeadings(context.Background(), root, &Page{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.findings, result.Findings)
+		})
+	}
+}
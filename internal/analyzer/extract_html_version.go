@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"context"
+
+	"golang.org/x/net/html"
+)
+
+const HtmlVersionAnalysis = "html_version"
+
+// HtmlVersionResult is the HTML version declared by the doctype and the document mode it results in.
+type HtmlVersionResult struct {
+	Version string
+	Mode    string
+}
+
+func init() {
+	MustRegister(NewExtractor(HtmlVersionAnalysis, ExtractHtmlVersion))
+}
+
+func ExtractHtmlVersion(ctx context.Context, root *html.Node, page *Page) (HtmlVersionResult, error) {
+	doctype := findDoctype(root)
+	xhtml := mediaType(page.ContentType) == "application/xhtml+xml"
+
+	return HtmlVersionResult{
+		Version: detectHTMLVersion(doctype, xhtml),
+		Mode:    documentMode(doctype, xhtml),
+	}, nil
+}
+
+func (v HtmlVersionResult) Apply(response *AnalyzerResponse) {
+	response.HtmlVersion = v.Version
+	response.DocumentMode = v.Mode
+}
@@ -1,47 +1,74 @@
 package analyzer
 
 import (
-	"log/slog"
+	"context"
 	"math"
-	"net/http"
 	"net/url"
+	"strings"
 	"sync"
-	"time"
 
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
 	"golang.org/x/net/html"
 )
 
-func ExtrackLinks(root *html.Node, pageUrl *url.URL, wg *sync.WaitGroup, resultChan chan AnalyzerResponse) {
-	start := time.Now()
-	status := "Success"
-	functionName := "ExtractLinks"
-	defer wg.Done()
+const LinksAnalysis = "links"
 
-	links := make([]Link, 0)
-	nodes := make([]html.Node, 0)
-	getMatchingNodes(root, &nodes, "a")
+func init() {
+	MustRegister(NewExtractor(LinksAnalysis, ExtrackLinks))
+}
+
+func ExtrackLinks(ctx context.Context, root *html.Node, page *Page) (*LinkSummaryResponse, error) {
+	links := collectLinks(root, page.Url)
 
 	// can execute this parallely
-	nodeChan := make(chan *html.Node, len(nodes))
+	// every worker only writes to the links of the indexes it receives, so no locking is needed
+	indexChan := make(chan int, len(links))
+	doneChan := make(chan int, len(links))
+	checked := make([]bool, len(links))
 	var linkWg sync.WaitGroup
-	workers := int(math.Sqrt(float64(len(nodes))) * 3)
+	workers := int(math.Sqrt(float64(len(links))) * 3)
 
 	for i := 0; i < workers; i++ {
 		linkWg.Add(1)
-		go setupLinks(nodeChan, pageUrl, &linkWg, &links)
+		go setupLinks(ctx, indexChan, doneChan, page.LinkChecker, &linkWg, links, checked)
+	}
+
+	// feed the link indexes to the index channel
+	for i := range links {
+		indexChan <- i
 	}
 
-	// feed the nodes to the node channel
-	for i := range nodes {
-		nodeChan <- &nodes[i]
+	close(indexChan)
+
+	go func() {
+		linkWg.Wait()
+		close(doneChan)
+	}()
+
+	// report every checked link as it comes in
+	progress := progressFromContext(ctx)
+	linksChecked := 0
+	for i := range doneChan {
+		linksChecked++
+		link := links[i]
+		progress(Progress{
+			Analysis:     LinksAnalysis,
+			Link:         &link,
+			LinksChecked: linksChecked,
+			LinksTotal:   len(links),
+		})
 	}
 
-	close(nodeChan)
+	// links which weren't checked in time are left out, the summary is partial then
+	checkedLinks := make([]Link, 0, len(links))
+	for i, link := range links {
+		if checked[i] {
+			checkedLinks = append(checkedLinks, link)
+		}
+	}
 
-	linkWg.Wait()
-	var accessibles, internals int
-	for _, link := range links {
+	var accessibles, internals, disallowed, unchecked int
+	categories := make(map[string]int)
+	for _, link := range checkedLinks {
 		if link.LinkType == "internal" {
 			internals++
 		}
@@ -49,27 +76,33 @@ func ExtrackLinks(root *html.Node, pageUrl *url.URL, wg *sync.WaitGroup, resultC
 		if link.Accessible {
 			accessibles++
 		}
+
+		switch link.Status.Category {
+		case LinkDisallowed:
+			disallowed++
+		case LinkUnresolved:
+			unchecked++
+		}
+
+		categories[link.Status.Category]++
 	}
 
-	resultChan <- AnalyzerResponse{
-		LinkSummary: &LinkSummaryResponse{
-			Links:             links,
-			InternalLinks:     internals,
-			ExternalLinks:     len(links) - internals,
-			AccessibleLinks:   accessibles,
-			InaccessibleLinks: len(links) - accessibles,
-		}}
-
-	duration := time.Since(start).Nanoseconds()
-	analyzerLogger.Info("Function Executed",
-		slog.String("function", functionName),
-		slog.Int64("duration", duration),
-	)
-
-	observability.
-		DurationMetrics.
-		WithLabelValues(functionName, status).
-		Observe(float64(duration))
+	return &LinkSummaryResponse{
+		Links:             checkedLinks,
+		InternalLinks:     internals,
+		ExternalLinks:     len(checkedLinks) - internals,
+		AccessibleLinks:   accessibles,
+		InaccessibleLinks: len(checkedLinks) - accessibles - disallowed - unchecked,
+		DisallowedLinks:   disallowed,
+		UncheckedLinks:    unchecked,
+		Categories:        categories,
+	}, ctx.Err()
+}
+
+func (s *LinkSummaryResponse) Apply(response *AnalyzerResponse) {
+	if s != nil && len(s.Links) > 0 {
+		response.LinkSummary = s
+	}
 }
 
 func getLinkType(linkURL, baseURL *url.URL) string {
@@ -81,41 +114,82 @@ func getLinkType(linkURL, baseURL *url.URL) string {
 	return "external"
 }
 
-func setupLinks(nodes <-chan *html.Node, baseUrl *url.URL, wg *sync.WaitGroup, links *[]Link) {
-	defer wg.Done()
-	for node := range nodes {
-		for _, attr := range node.Attr {
-			if attr.Key == "href" {
-				// we have a link now.
-				linkUrl, err := url.Parse(attr.Val)
-				if err != nil {
-					// validate the URL and ignore if invalid
-					continue
+// collectLinks returns the links of the page in document order.
+func collectLinks(root *html.Node, baseUrl *url.URL) []Link {
+	links := make([]Link, 0)
+	position := 0
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if node.Data == "a" {
+				if link, ok := newLink(node, baseUrl); ok {
+					link.Position = position
+					links = append(links, link)
 				}
+			}
+			position++
+		}
 
-				// If the link is relative, resolve it to an absolute URL
-				if !linkUrl.IsAbs() {
-					linkUrl = baseUrl.ResolveReference(linkUrl)
-				}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
 
-				// checking the accessibility of the link can be run in parallel
-				// so it will be implemented once we have all the links extracted
-				client := &http.Client{Timeout: 3 * time.Second}
+	return links
+}
 
-				var accessible bool
-				resp, _ := client.Head(linkUrl.String())
-				if resp != nil && resp.StatusCode == http.StatusOK {
-					accessible = true
-					resp.Body.Close()
-				}
+func newLink(node *html.Node, baseUrl *url.URL) (Link, bool) {
+	href, ok := getAttribute(node, "href")
+	if !ok {
+		return Link{}, false
+	}
 
-				*links = append(*links, Link{
-					LinkType:   getLinkType(linkUrl, baseUrl),
-					LinkUrl:    linkUrl.String(),
-					Accessible: accessible,
-				})
+	// we have a link now.
+	linkUrl, err := url.Parse(href)
+	if err != nil {
+		// validate the URL and ignore if invalid
+		return Link{}, false
+	}
 
-			}
+	// If the link is relative, resolve it to an absolute URL
+	if !linkUrl.IsAbs() {
+		linkUrl = baseUrl.ResolveReference(linkUrl)
+	}
+
+	rel, _ := getAttribute(node, "rel")
+
+	return Link{
+		LinkType: getLinkType(linkUrl, baseUrl),
+		LinkUrl:  linkUrl.String(),
+		Text:     getText(node),
+		Rel:      strings.Fields(rel),
+	}, true
+}
+
+func setupLinks(ctx context.Context, indexes <-chan int, done chan<- int, checker *LinkChecker, wg *sync.WaitGroup, links []Link, checked []bool) {
+	defer wg.Done()
+	for i := range indexes {
+		// the remaining links are skipped once the analysis is cancelled
+		if ctx.Err() != nil {
+			continue
 		}
+
+		// HTML analyzed without a base url leaves its relative links with nowhere to check them
+		status := LinkStatus{Category: LinkUnresolved}
+		if linkUrl, err := url.Parse(links[i].LinkUrl); err == nil && linkUrl.IsAbs() {
+			status = checker.Check(ctx, links[i].LinkUrl)
+		}
+
+		// a check cut short by the cancellation says nothing about the link
+		if ctx.Err() != nil {
+			continue
+		}
+
+		links[i].Status = status
+		links[i].Accessible = status.Accessible()
+		checked[i] = true
+		done <- i
 	}
 }
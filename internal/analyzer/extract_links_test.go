@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestCollectLinks(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_links.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	baseUrl, _ := url.Parse("http://example.com/page")
+	links := collectLinks(root, baseUrl)
+
+	assert.Len(t, links, 5)
+	assert.Equal(t, "https://www.google.com", links[0].LinkUrl)
+	assert.Equal(t, "Google", links[0].Text)
+	assert.Equal(t, "external", links[0].LinkType)
+	assert.Equal(t, "http://example.com/internal?query=123", links[4].LinkUrl)
+	assert.Equal(t, "Internal Link", links[4].Text)
+	assert.Equal(t, "internal", links[4].LinkType)
+
+	for i := 1; i < len(links); i++ {
+		assert.Greater(t, links[i].Position, links[i-1].Position)
+	}
+}
+
+func TestCollectLinks_TextAndRel(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<p>Intro</p><a href="/a" rel="nofollow  noopener"> Read <b>more</b>
+		here </a><a>No href</a><a href="/b">B</a>`))
+	assert.NoError(t, err)
+
+	baseUrl, _ := url.Parse("http://example.com")
+	links := collectLinks(root, baseUrl)
+
+	assert.Len(t, links, 2)
+	assert.Equal(t, "Read more here", links[0].Text)
+	assert.Equal(t, []string{"nofollow", "noopener"}, links[0].Rel)
+	assert.Empty(t, links[1].Rel)
+}
+
+func TestExtractLinks_DeterministicOrder(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			if strings.HasSuffix(r.URL.Path, "0") {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "<!DOCTYPE html><html><body>")
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(w, `<a href="/page/%d">Page %d</a>`, i, i)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	defer testServer.Close()
+
+	// run a few times, the order must not change between runs
+	for run := 0; run < 3; run++ {
+		response, err := Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL})
+		assert.NoError(t, err)
+
+		links := response.LinkSummary.Links
+		assert.Len(t, links, 100)
+		for i, link := range links {
+			assert.Equal(t, fmt.Sprintf("%s/page/%d", testServer.URL, i), link.LinkUrl)
+			assert.Equal(t, fmt.Sprintf("Page %d", i), link.Text)
+			assert.Equal(t, i%10 != 0, link.Accessible)
+		}
+
+		assert.Equal(t, 100, response.LinkSummary.InternalLinks)
+		assert.Equal(t, 90, response.LinkSummary.AccessibleLinks)
+		assert.Equal(t, 10, response.LinkSummary.InaccessibleLinks)
+		assert.Equal(t, map[string]int{LinkOK: 90, LinkClientError: 10}, response.LinkSummary.Categories)
+	}
+}
@@ -1,50 +1,272 @@
 package analyzer
 
 import (
-	"log/slog"
-	"sync"
-	"time"
+	"context"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
 	"golang.org/x/net/html"
 )
 
-func ExtractLoginForm(root *html.Node, wg *sync.WaitGroup, resultChan chan AnalyzerResponse) {
-	start := time.Now()
-	status := "Success"
-	functionName := "ExtractLoginForm"
-	defer wg.Done()
+const LoginFormAnalysis = "login_form"
 
-	resultChan <- AnalyzerResponse{HasLoginForm: hasLoginForm(root)}
+// Auth form types
+const (
+	LoginForm         = "login"
+	SignupForm        = "signup"
+	PasswordResetForm = "password_reset"
+	MFAForm           = "mfa"
+)
+
+// LoginFormResult is the authentication forms of the page and its single sign-on buttons.
+type LoginFormResult struct {
+	Forms      []AuthForm
+	SSOButtons []SSOButton
+}
+
+type AuthForm struct {
+	Type         string      // login, signup, password_reset or mfa
+	Action       string      // url the form submits to
+	Method       string      // GET or POST
+	Secure       bool        // true if the form submits over HTTPS
+	Autocomplete string      // autocomplete attribute of the form, "off" disables it for all fields
+	Fields       []FormField // fields of the form, buttons excluded
+}
+
+type FormField struct {
+	Tag          string // input, select or textarea
+	Type         string // type of the input
+	Name         string
+	Autocomplete string // autocomplete hint, "current-password" or "one-time-code" for instance
+	Required     bool
+}
+
+// SSOButton is a button or a link to sign in with another provider.
+type SSOButton struct {
+	Provider string // "Google" for "Sign in with Google"
+	Text     string
+	Url      string // link of the button, empty for buttons
+}
+
+func init() {
+	MustRegister(NewExtractor(LoginFormAnalysis, ExtractLoginForm))
+}
+
+func ExtractLoginForm(ctx context.Context, root *html.Node, page *Page) (LoginFormResult, error) {
+	result := LoginFormResult{
+		Forms:      make([]AuthForm, 0),
+		SSOButtons: findSSOButtons(root, page.Url),
+	}
+
+	forms := make([]html.Node, 0)
+	getMatchingNodes(root, &forms, "form")
+	for i := range forms {
+		if form, ok := newAuthForm(&forms[i], page.Url); ok {
+			result.Forms = append(result.Forms, form)
+		}
+	}
+
+	return result, nil
+}
+
+func (l LoginFormResult) Apply(response *AnalyzerResponse) {
+	response.AuthForms = l.Forms
+	response.SSOButtons = l.SSOButtons
+	response.HasLoginForm = slices.ContainsFunc(l.Forms, func(form AuthForm) bool { return form.Type == LoginForm })
+}
+
+var (
+	resetHint  = regexp.MustCompile(`(?i)reset|forgot|recover|lost`)
+	signupHint = regexp.MustCompile(`(?i)sign[\s_-]?up|regist|create|join`)
+	otpHint    = regexp.MustCompile(`(?i)otp|totp|mfa|2fa|two[\s_-]?factor|verification|one[\s_-]?time`)
+	userHint   = regexp.MustCompile(`(?i)user|email|login`)
+)
+
+// newAuthForm classifies the form by its own fields only, ok is false when it isn't an auth form.
+func newAuthForm(node *html.Node, baseUrl *url.URL) (AuthForm, bool) {
+	form := AuthForm{Fields: make([]FormField, 0)}
+	form.Autocomplete, _ = getAttribute(node, "autocomplete")
+
+	var passwords int
+	var newPassword, identity, otp, submit bool
+	hints := formHints(node)
+
+	fields := make([]html.Node, 0)
+	getMatchingNodes(node, &fields, "input", "select", "textarea")
+	for _, field := range fields {
+		fieldType, _ := getAttribute(&field, "type")
+		fieldType = strings.ToLower(fieldType)
+		if field.Data == "input" && fieldType == "" {
+			fieldType = "text"
+		}
+		if slices.Contains([]string{"submit", "button", "image", "reset"}, fieldType) {
+			submit = submit || fieldType == "submit" || fieldType == "image"
+			continue
+		}
+
+		name, _ := getAttribute(&field, "name")
+		id, _ := getAttribute(&field, "id")
+		autocomplete, _ := getAttribute(&field, "autocomplete")
+		_, required := getAttribute(&field, "required")
+		form.Fields = append(form.Fields, FormField{
+			Tag:          field.Data,
+			Type:         fieldType,
+			Name:         name,
+			Autocomplete: autocomplete,
+			Required:     required,
+		})
+
+		tokens := strings.Fields(strings.ToLower(autocomplete))
+		switch {
+		case fieldType == "password":
+			passwords++
+			newPassword = newPassword || slices.Contains(tokens, "new-password")
+		case fieldType == "hidden":
+		case slices.Contains(tokens, "one-time-code") || otpHint.MatchString(name+" "+id):
+			otp = true
+		case fieldType == "email" || slices.Contains(tokens, "username") || slices.Contains(tokens, "email") ||
+			userHint.MatchString(name+" "+id):
+			identity = true
+		}
+	}
 
-	duration := time.Since(start).Nanoseconds()
-	analyzerLogger.Info("Function Executed",
-		slog.String("function", functionName),
-		slog.Int64("duration", duration),
-	)
+	buttons := make([]html.Node, 0)
+	getMatchingNodes(node, &buttons, "button")
+	for _, button := range buttons {
+		// buttons submit unless they say otherwise
+		buttonType, _ := getAttribute(&button, "type")
+		submit = submit || buttonType == "" || strings.EqualFold(buttonType, "submit")
+	}
+
+	switch {
+	case passwords == 0 && otp:
+		form.Type = MFAForm
+	case passwords == 0 && identity && resetHint.MatchString(hints):
+		form.Type = PasswordResetForm
+	case passwords == 0:
+		return AuthForm{}, false
+	// a lone password field which can't be submitted, a newsletter preference for instance
+	case !identity && !submit:
+		return AuthForm{}, false
+	// setting a new password after following the reset link
+	case !identity && resetHint.MatchString(hints):
+		form.Type = PasswordResetForm
+	case newPassword || passwords > 1 || signupHint.MatchString(hints):
+		form.Type = SignupForm
+	default:
+		form.Type = LoginForm
+	}
+
+	action, _ := getAttribute(node, "action")
+	form.Action = resolveUrl(action, baseUrl)
+	form.Secure = strings.HasPrefix(strings.ToLower(form.Action), "https:")
+
+	method, _ := getAttribute(node, "method")
+	form.Method = strings.ToUpper(method)
+	if form.Method == "" {
+		form.Method = "GET"
+	}
+
+	return form, true
+}
 
-	observability.
-		DurationMetrics.
-		WithLabelValues(functionName, status).
-		Observe(float64(duration))
+// formHints are what tells the purpose of the form apart from its fields, its attributes and submit buttons.
+// The rest of its text isn't included, a login form often has a "Forgot password?" link.
+func formHints(node *html.Node) string {
+	hints := make([]string, 0)
+	for _, key := range []string{"id", "name", "class", "action"} {
+		if value, ok := getAttribute(node, key); ok {
+			hints = append(hints, value)
+		}
+	}
+
+	buttons := make([]html.Node, 0)
+	getMatchingNodes(node, &buttons, "button", "input", "legend")
+	for _, button := range buttons {
+		buttonType, _ := getAttribute(&button, "type")
+		switch {
+		case button.Data == "input" && (buttonType == "submit" || buttonType == "button"):
+			value, _ := getAttribute(&button, "value")
+			hints = append(hints, value)
+		case button.Data != "input":
+			hints = append(hints, getText(&button))
+		}
+	}
+
+	return strings.Join(hints, " ")
 }
 
-func hasLoginForm(node *html.Node) bool {
-	// if the node data is input check if the input type is password and submit
+var (
+	ssoText = regexp.MustCompile(`(?i)(?:((?:sign|log)\s*(?:in|on|up)|login)|continue|connect)\s+(?:with|using|via)\s+(.+)`)
+	// "Sign in with your email" is the form itself rather than another provider
+	ssoNotProvider = regexp.MustCompile(`(?i)e-?mail|password|phone|user\s*name|account|passkey`)
+)
+
+// known providers, by the text of the button
+var ssoProviders = []struct {
+	name  string
+	match string
+}{
+	{"Google", "google"},
+	{"Apple", "apple"},
+	{"Microsoft", "microsoft"},
+	{"Facebook", "facebook"},
+	{"GitHub", "github"},
+	{"GitLab", "gitlab"},
+	{"LinkedIn", "linkedin"},
+	{"Twitter", "twitter"},
+	{"Okta", "okta"},
+	{"Auth0", "auth0"},
+	{"Slack", "slack"},
+}
+
+// findSSOButtons finds the buttons and links which start a sign in with another provider.
+func findSSOButtons(root *html.Node, baseUrl *url.URL) []SSOButton {
+	buttons := make([]SSOButton, 0)
+
 	nodes := make([]html.Node, 0)
-	getMatchingNodes(node, &nodes, "input", "button")
-	var hasPasswordField, hasSubmitButton bool
+	getMatchingNodes(root, &nodes, "a", "button")
 	for _, node := range nodes {
-		for _, attr := range node.Attr {
-			if attr.Key == "type" && attr.Val == "password" {
-				hasPasswordField = true
-			}
+		text := getText(&node)
+		if text == "" {
+			text, _ = getAttribute(&node, "aria-label")
+		}
+
+		match := ssoText.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+
+		// "Continue with checkout" isn't a sign in, only the known providers count after it
+		provider, ok := ssoProvider(match[2], match[1] != "")
+		if !ok {
+			continue
+		}
 
-			if attr.Key == "type" && (attr.Val == "submit" || attr.Val == "button") {
-				hasSubmitButton = true
-			}
+		button := SSOButton{Provider: provider, Text: text}
+		if href, ok := getAttribute(&node, "href"); ok && node.Data == "a" {
+			button.Url = resolveUrl(href, baseUrl)
 		}
+		buttons = append(buttons, button)
+	}
+
+	return buttons
+}
+
+// ssoProvider names the provider, the unknown ones are named as the button does when it signs in.
+func ssoProvider(name string, signIn bool) (string, bool) {
+	lower := strings.ToLower(name)
+	for _, provider := range ssoProviders {
+		if strings.Contains(lower, provider.match) {
+			return provider.name, true
+		}
+	}
+
+	if !signIn || ssoNotProvider.MatchString(name) {
+		return "", false
 	}
 
-	return hasPasswordField && hasSubmitButton
+	return strings.TrimSpace(name), true
 }
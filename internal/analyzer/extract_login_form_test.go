@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractLoginForm(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_auth_forms.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	pageUrl, _ := url.Parse("http://example.com/account")
+	result, err := ExtractLoginForm(context.Background(), root, &Page{Url: pageUrl})
+	assert.NoError(t, err)
+
+	assert.Len(t, result.Forms, 4)
+
+	login := result.Forms[0]
+	assert.Equal(t, LoginForm, login.Type)
+	assert.Equal(t, "http://example.com/login", login.Action)
+	assert.Equal(t, "POST", login.Method)
+	assert.False(t, login.Secure)
+	assert.Equal(t, "on", login.Autocomplete)
+	assert.Equal(t, []FormField{
+		{Tag: "input", Type: "hidden", Name: "csrf"},
+		{Tag: "input", Type: "text", Name: "username", Autocomplete: "username", Required: true},
+		{Tag: "input", Type: "password", Name: "password", Autocomplete: "current-password", Required: true},
+	}, login.Fields)
+
+	signup := result.Forms[1]
+	assert.Equal(t, SignupForm, signup.Type)
+	assert.Equal(t, "https://example.com/register", signup.Action)
+	assert.True(t, signup.Secure)
+	assert.Len(t, signup.Fields, 3)
+
+	assert.Equal(t, PasswordResetForm, result.Forms[2].Type)
+	assert.Equal(t, MFAForm, result.Forms[3].Type)
+	assert.Equal(t, "one-time-code", result.Forms[3].Fields[0].Autocomplete)
+
+	// "Sign in with email", "Continue with checkout" and "Connect with us" aren't providers
+	assert.Equal(t, []SSOButton{
+		{Provider: "Google", Text: "Sign in with Google", Url: "https://accounts.google.com/o/oauth2/auth?client_id=1"},
+		{Provider: "GitHub", Text: "Continue with GitHub"},
+		{Provider: "Acme ID", Text: "Log in with Acme ID"},
+	}, result.SSOButtons)
+
+	response := &AnalyzerResponse{}
+	result.Apply(response)
+	assert.True(t, response.HasLoginForm)
+}
+
+func TestExtractLoginForm_FieldsInDifferentForms(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+		<form action="/search"><input type="text" name="q"><button type="submit">Search</button></form>
+		<form action="/newsletter"><input type="password" name="secret"></form>
+		<input type="password" name="orphan"><button type="button">Go</button>
+		<form action="/unlock"><input type="password" name="password"><button>Unlock</button></form>`))
+	assert.NoError(t, err)
+
+	result, err := ExtractLoginForm(context.Background(), root, &Page{Url: &url.URL{}})
+	assert.NoError(t, err)
+
+	// the search button doesn't submit the newsletter password, only the form with both is a login form
+	assert.Len(t, result.Forms, 1)
+	assert.Equal(t, LoginForm, result.Forms[0].Type)
+	assert.Equal(t, "/unlock", result.Forms[0].Action)
+	assert.Equal(t, "GET", result.Forms[0].Method)
+}
+
+func TestExtractLoginForm_PasswordReset(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+		<form action="/password/reset" method="post">
+			<input type="hidden" name="token" value="abc">
+			<input type="password" name="password" autocomplete="new-password">
+			<input type="password" name="confirm" autocomplete="new-password">
+			<button type="submit">Reset password</button>
+		</form>`))
+	assert.NoError(t, err)
+
+	result, err := ExtractLoginForm(context.Background(), root, &Page{Url: &url.URL{}})
+	assert.NoError(t, err)
+
+	assert.Len(t, result.Forms, 1)
+	assert.Equal(t, PasswordResetForm, result.Forms[0].Type)
+}
@@ -0,0 +1,289 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const SEOAnalysis = "seo"
+
+// SEO rules
+const (
+	RuleMissingTitle       = "missing-title"
+	RuleTitleLength        = "title-length"
+	RuleMissingDescription = "missing-description"
+	RuleDescriptionLength  = "description-length"
+	RuleMissingViewport    = "missing-viewport"
+	RuleMissingCanonical   = "missing-canonical"
+	RuleNoIndex            = "noindex"
+	RuleDuplicateTag       = "duplicate-tag"
+	RuleConflictingTags    = "conflicting-tags"
+)
+
+// lengths search engines show in full
+const (
+	minTitleLength       = 10
+	maxTitleLength       = 60
+	minDescriptionLength = 50
+	maxDescriptionLength = 160
+)
+
+// SEOResult is the metadata search engines and social networks read from the page.
+type SEOResult struct {
+	Title       string
+	Description string            // meta description
+	Canonical   string            // canonical url, resolved against the page url
+	Robots      []string          // directives of the robots meta tag and the X-Robots-Tag header
+	Indexable   bool              // false when a robots directive keeps the page out of the index
+	Viewport    string            // viewport meta tag
+	Alternates  []Alternate       // hreflang alternates
+	OpenGraph   map[string]string // og: properties, the first one when a property repeats
+	TwitterCard map[string]string // twitter: properties, the first one when a property repeats
+	Findings    []Finding
+}
+
+// Alternate is the page in another language or region.
+type Alternate struct {
+	Lang string // hreflang, "x-default" included
+	Url  string
+}
+
+// key of the title element, <meta name="title"> is a tag of its own which CMSes often fill differently
+const titleKey = "<title>"
+
+// tags which only make sense once on a page
+var singleTags = []string{
+	titleKey, "description", "viewport", "canonical",
+	"og:title", "og:description", "og:url", "og:type", "og:site_name",
+	"twitter:card", "twitter:title", "twitter:description", "twitter:site", "twitter:creator",
+}
+
+func init() {
+	MustRegister(NewExtractor(SEOAnalysis, ExtractSEO))
+}
+
+func ExtractSEO(ctx context.Context, root *html.Node, page *Page) (SEOResult, error) {
+	tags := collectSEOTags(root, page.Url)
+
+	result := SEOResult{
+		Title:       firstValue(tags[titleKey]),
+		Description: firstValue(tags["description"]),
+		Canonical:   firstValue(tags["canonical"]),
+		Viewport:    firstValue(tags["viewport"]),
+		Robots:      robotsDirectives(tags["robots"], page.Header.Values("X-Robots-Tag")),
+		Alternates:  collectAlternates(root, page.Url),
+		OpenGraph:   make(map[string]string),
+		TwitterCard: make(map[string]string),
+	}
+
+	for key, values := range tags {
+		if strings.HasPrefix(key, "og:") {
+			result.OpenGraph[key] = values[0]
+		}
+		if strings.HasPrefix(key, "twitter:") {
+			result.TwitterCard[key] = values[0]
+		}
+	}
+
+	result.Indexable = !slices.Contains(result.Robots, "noindex") && !slices.Contains(result.Robots, "none")
+	result.Findings = seoFindings(&result, tags)
+
+	return result, nil
+}
+
+func (s SEOResult) Apply(response *AnalyzerResponse) {
+	response.SEO = &s
+}
+
+// collectSEOTags gathers the values of the title, meta and canonical tags by their name, in document order.
+// The title element is kept under titleKey, apart from the meta tags.
+func collectSEOTags(root *html.Node, baseUrl *url.URL) map[string][]string {
+	tags := make(map[string][]string)
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		// svg has a title element of its own
+		if node.Type == html.ElementNode && node.Namespace == "" {
+			switch node.Data {
+			case "title":
+				tags[titleKey] = append(tags[titleKey], getText(node))
+			case "meta":
+				// Open Graph uses property, the others name
+				key, ok := getAttribute(node, "property")
+				if !ok {
+					key, _ = getAttribute(node, "name")
+				}
+				content, ok := getAttribute(node, "content")
+				if key = strings.ToLower(strings.TrimSpace(key)); key != "" && ok {
+					tags[key] = append(tags[key], strings.TrimSpace(content))
+				}
+			case "link":
+				rel, _ := getAttribute(node, "rel")
+				href, ok := getAttribute(node, "href")
+				if ok && slices.Contains(strings.Fields(strings.ToLower(rel)), "canonical") {
+					canonical := resolveUrl(href, baseUrl)
+					tags["canonical"] = append(tags["canonical"], canonical)
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return tags
+}
+
+func collectAlternates(root *html.Node, baseUrl *url.URL) []Alternate {
+	alternates := make([]Alternate, 0)
+
+	nodes := make([]html.Node, 0)
+	getMatchingNodes(root, &nodes, "link")
+	for _, node := range nodes {
+		rel, _ := getAttribute(&node, "rel")
+		lang, hasLang := getAttribute(&node, "hreflang")
+		href, hasHref := getAttribute(&node, "href")
+		if !hasLang || !hasHref || !slices.Contains(strings.Fields(strings.ToLower(rel)), "alternate") {
+			continue
+		}
+
+		alternateUrl := resolveUrl(href, baseUrl)
+		alternates = append(alternates, Alternate{Lang: strings.ToLower(strings.TrimSpace(lang)), Url: alternateUrl})
+	}
+
+	return alternates
+}
+
+// directives which take a value after a colon, anything else before one is the name of a bot
+var robotsValueDirectives = []string{"max-snippet", "max-image-preview", "max-video-preview", "unavailable_after"}
+
+// robotsDirectives merges the directives of the meta tags and the headers.
+func robotsDirectives(metas []string, headers []string) []string {
+	directives := make([]string, 0)
+
+	add := func(value string) {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive != "" && !slices.Contains(directives, directive) {
+				directives = append(directives, directive)
+			}
+		}
+	}
+
+	for _, meta := range metas {
+		add(meta)
+	}
+	for _, header := range headers {
+		// "googlebot: noindex" only applies to that bot, "max-snippet: 0" is a directive though
+		if bot, _, found := strings.Cut(header, ":"); found && !strings.ContainsAny(bot, ", ") &&
+			!slices.Contains(robotsValueDirectives, strings.ToLower(bot)) {
+			continue
+		}
+		add(header)
+	}
+
+	return directives
+}
+
+func seoFindings(result *SEOResult, tags map[string][]string) []Finding {
+	findings := make([]Finding, 0)
+
+	titleLength := utf8.RuneCountInString(result.Title)
+	switch {
+	case titleLength == 0:
+		findings = append(findings, Finding{Rule: RuleMissingTitle, Severity: SeverityError, Message: "page has no title"})
+	case titleLength < minTitleLength || titleLength > maxTitleLength:
+		findings = append(findings, Finding{
+			Rule:     RuleTitleLength,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("title is %d characters, %d to %d are recommended", titleLength, minTitleLength, maxTitleLength),
+		})
+	}
+
+	descriptionLength := utf8.RuneCountInString(result.Description)
+	switch {
+	case descriptionLength == 0:
+		findings = append(findings, Finding{Rule: RuleMissingDescription, Severity: SeverityWarning, Message: "page has no meta description"})
+	case descriptionLength < minDescriptionLength || descriptionLength > maxDescriptionLength:
+		findings = append(findings, Finding{
+			Rule:     RuleDescriptionLength,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("meta description is %d characters, %d to %d are recommended", descriptionLength, minDescriptionLength, maxDescriptionLength),
+		})
+	}
+
+	if result.Viewport == "" {
+		findings = append(findings, Finding{Rule: RuleMissingViewport, Severity: SeverityWarning, Message: "page has no viewport meta tag"})
+	}
+
+	if result.Canonical == "" {
+		findings = append(findings, Finding{Rule: RuleMissingCanonical, Severity: SeverityInfo, Message: "page has no canonical url"})
+	}
+
+	if !result.Indexable {
+		findings = append(findings, Finding{Rule: RuleNoIndex, Severity: SeverityWarning, Message: "robots directives keep the page out of search results"})
+	}
+
+	for _, key := range singleTags {
+		if finding, ok := repeatedTag(key, tags[key]); ok {
+			findings = append(findings, finding)
+		}
+	}
+
+	if slices.Contains(result.Robots, "index") && !result.Indexable {
+		findings = append(findings, Finding{Rule: RuleConflictingTags, Severity: SeverityError, Message: "robots directives have both index and noindex"})
+	}
+
+	// the same language pointing to different pages
+	urls := make(map[string]string)
+	for _, alternate := range result.Alternates {
+		if previous, ok := urls[alternate.Lang]; ok && previous != alternate.Url {
+			findings = append(findings, Finding{
+				Rule:     RuleConflictingTags,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("hreflang %s points to %s and %s", alternate.Lang, previous, alternate.Url),
+			})
+		}
+		urls[alternate.Lang] = alternate.Url
+	}
+
+	return findings
+}
+
+// repeatedTag reports a tag which is on the page more than once, as a conflict when the values differ.
+func repeatedTag(key string, values []string) (Finding, bool) {
+	if len(values) < 2 {
+		return Finding{}, false
+	}
+
+	for _, value := range values[1:] {
+		if value != values[0] {
+			return Finding{
+				Rule:     RuleConflictingTags,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s has %d different values", key, len(values)),
+			}, true
+		}
+	}
+
+	return Finding{
+		Rule:     RuleDuplicateTag,
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("%s is repeated %d times", key, len(values)),
+	}, true
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractSEO(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_seo.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	pageUrl, _ := url.Parse("https://example.com/tools/")
+	result, err := ExtractSEO(context.Background(), root, &Page{Url: pageUrl})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Go Web Analyzer - analyze the structure of any web page", result.Title)
+	assert.Equal(t, "Analyze the HTML version, headings, links and forms of a web page from your browser.", result.Description)
+	assert.Equal(t, "https://example.com/analyzer", result.Canonical)
+	assert.Equal(t, "width=device-width, initial-scale=1.0", result.Viewport)
+	assert.Equal(t, []string{"index", "follow"}, result.Robots)
+	assert.True(t, result.Indexable)
+	assert.Equal(t, []Alternate{
+		{Lang: "en", Url: "https://example.com/analyzer"},
+		{Lang: "de", Url: "https://example.com/de/analyzer"},
+		{Lang: "x-default", Url: "https://example.com/analyzer"},
+	}, result.Alternates)
+	assert.Equal(t, map[string]string{
+		"og:title": "Go Web Analyzer",
+		"og:type":  "website",
+		"og:image": "https://example.com/og.png",
+	}, result.OpenGraph)
+	assert.Equal(t, map[string]string{
+		"twitter:card":  "summary_large_image",
+		"twitter:title": "Go Web Analyzer",
+	}, result.TwitterCard)
+
+	// og:image may repeat, twitter:title may not
+	assert.Equal(t, []Finding{
+		{Rule: RuleDuplicateTag, Severity: SeverityInfo, Message: "twitter:title is repeated 2 times"},
+	}, result.Findings)
+}
+
+func TestExtractSEO_Findings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		header   http.Header
+		findings []string
+	}{
+		{
+			name:     "Missing tags",
+			content:  "<html><head></head><body></body></html>",
+			findings: []string{RuleMissingTitle, RuleMissingDescription, RuleMissingViewport, RuleMissingCanonical},
+		},
+		{
+			name: "Lengths",
+			content: `<title>Short</title><meta name="description" content="Too short">
+				<meta name="viewport" content="width=device-width"><link rel="canonical" href="https://example.com">`,
+			findings: []string{RuleTitleLength, RuleDescriptionLength},
+		},
+		{
+			name: "Conflicting values",
+			content: `<title>A title which is long enough</title><title>Another title which is long enough</title>
+				<meta name="viewport" content="width=device-width"><link rel="canonical" href="https://example.com/a"><link rel="canonical" href="https://example.com/b">
+				<link rel="alternate" hreflang="en" href="https://example.com/a"><link rel="alternate" hreflang="en" href="https://example.com/c">`,
+			findings: []string{RuleMissingDescription, RuleConflictingTags, RuleConflictingTags, RuleConflictingTags},
+		},
+		{
+			name: "Meta title isn't the title element",
+			content: `<title>A title which is long enough</title><meta name="title" content="A title which is long enough - Site">
+				<meta name="viewport" content="width=device-width"><link rel="canonical" href="https://example.com">`,
+			findings: []string{RuleMissingDescription},
+		},
+		{
+			name: "Noindex header",
+			content: `<title>A title which is long enough</title><meta name="viewport" content="width=device-width">
+				<link rel="canonical" href="https://example.com"><meta name="robots" content="index">`,
+			header:   http.Header{"X-Robots-Tag": {"noindex", "googlebot: nofollow"}},
+			findings: []string{RuleMissingDescription, RuleNoIndex, RuleConflictingTags},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.content))
+			assert.NoError(t, err)
+
+			result, err := ExtractSEO(context.Background(), root, &Page{Url: &url.URL{}, Header: tt.header})
+			assert.NoError(t, err)
+
+			rules := make([]string, 0)
+			for _, finding := range result.Findings {
+				rules = append(rules, finding.Rule)
+			}
+			assert.Equal(t, tt.findings, rules)
+		})
+	}
+}
+
+func TestRobotsDirectives(t *testing.T) {
+	directives := robotsDirectives([]string{"NoIndex, follow"}, []string{
+		"noarchive", "googlebot: nosnippet", "unavailable_after: 25 Jun 2030 15:00:00 PST",
+		"max-snippet:0", "Max-Image-Preview: large", "max-video-preview:-1",
+	})
+	assert.Equal(t, []string{
+		"noindex", "follow", "noarchive", "unavailable_after: 25 jun 2030 15:00:00 pst",
+		"max-snippet:0", "max-image-preview: large", "max-video-preview:-1",
+	}, directives)
+}
+
+func TestAnalyze_XRobotsTag(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+		w.Write([]byte("<html><head><title>Private</title></head></html>"))
+	}))
+	defer testServer.Close()
+
+	response, err := Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL, Analyses: []string{SEOAnalysis}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"noindex", "nofollow"}, response.SEO.Robots)
+	assert.False(t, response.SEO.Indexable)
+}
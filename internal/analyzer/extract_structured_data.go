@@ -0,0 +1,346 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const StructuredDataAnalysis = "structured_data"
+
+// Structured data formats
+const (
+	JSONLD    = "json-ld"
+	Microdata = "microdata"
+	RDFa      = "rdfa"
+)
+
+// Structured data rules
+const (
+	RuleInvalidJSONLD         = "invalid-json-ld"
+	RuleMissingProperty       = "missing-required-property"
+	RuleInvalidBreadcrumbItem = "invalid-breadcrumb-item"
+)
+
+// StructuredDataResult is the schema.org entities of the page.
+type StructuredDataResult struct {
+	Entities []Entity
+	Findings []Finding
+}
+
+// Entity is a schema.org item, a Product for instance.
+type Entity struct {
+	Format     string         // json-ld, microdata or rdfa
+	Type       string         // schema.org type without the vocabulary, empty when it has none
+	Properties map[string]any // strings, numbers or bools, nested entities and lists of them
+}
+
+// properties the common types need, one of the alternatives of each is enough
+var requiredProperties = map[string][][]string{
+	"Product":        {{"name"}, {"offers", "review", "aggregateRating"}},
+	"Article":        {{"headline"}, {"author"}, {"datePublished"}},
+	"NewsArticle":    {{"headline"}, {"author"}, {"datePublished"}},
+	"BlogPosting":    {{"headline"}, {"author"}, {"datePublished"}},
+	"BreadcrumbList": {{"itemListElement"}},
+	"Organization":   {{"name"}, {"url"}},
+}
+
+func init() {
+	MustRegister(NewExtractor(StructuredDataAnalysis, ExtractStructuredData))
+}
+
+func ExtractStructuredData(ctx context.Context, root *html.Node, page *Page) (StructuredDataResult, error) {
+	result := StructuredDataResult{Entities: make([]Entity, 0), Findings: make([]Finding, 0)}
+
+	scripts := make([]html.Node, 0)
+	getMatchingNodes(root, &scripts, "script")
+	count := 0
+	for _, script := range scripts {
+		scriptType, _ := getAttribute(&script, "type")
+		if !strings.EqualFold(strings.TrimSpace(scriptType), "application/ld+json") {
+			continue
+		}
+		count++
+
+		entities, err := parseJSONLD(&script)
+		if err != nil {
+			result.Findings = append(result.Findings, Finding{
+				Rule:     RuleInvalidJSONLD,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("JSON-LD script %d isn't valid JSON: %v", count, err),
+			})
+			continue
+		}
+		result.Entities = append(result.Entities, entities...)
+	}
+
+	result.Entities = append(result.Entities, collectItems(root, Microdata)...)
+	result.Entities = append(result.Entities, collectItems(root, RDFa)...)
+
+	for _, entity := range result.Entities {
+		result.Findings = append(result.Findings, validateEntity(entity)...)
+	}
+
+	return result, nil
+}
+
+func (s StructuredDataResult) Apply(response *AnalyzerResponse) {
+	response.StructuredData = &s
+}
+
+// parseJSONLD returns the entities of the script, the top level ones and the ones of a @graph.
+func parseJSONLD(script *html.Node) ([]Entity, error) {
+	var content strings.Builder
+	for child := script.FirstChild; child != nil; child = child.NextSibling {
+		content.WriteString(child.Data)
+	}
+
+	var data any
+	if err := json.Unmarshal([]byte(content.String()), &data); err != nil {
+		return nil, err
+	}
+
+	items := []any{data}
+	if list, ok := data.([]any); ok {
+		items = list
+	}
+
+	entities := make([]Entity, 0)
+	// the @graph items are appended, so they're visited too
+	for i := 0; i < len(items); i++ {
+		object, ok := items[i].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if graph, ok := object["@graph"].([]any); ok {
+			items = append(items, graph...)
+			continue
+		}
+		entities = append(entities, jsonLDEntity(object))
+	}
+
+	return entities, nil
+}
+
+func jsonLDEntity(object map[string]any) Entity {
+	entity := Entity{Format: JSONLD, Properties: make(map[string]any)}
+
+	switch types := object["@type"].(type) {
+	case string:
+		entity.Type = schemaName(types)
+	case []any:
+		if len(types) > 0 {
+			name, _ := types[0].(string)
+			entity.Type = schemaName(name)
+		}
+	}
+
+	for key, value := range object {
+		if key == "@context" || key == "@type" {
+			continue
+		}
+		entity.Properties[key] = jsonLDValue(value)
+	}
+
+	return entity
+}
+
+// jsonLDValue turns the nested objects into entities so they're validated the same as the other formats.
+func jsonLDValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		return jsonLDEntity(value)
+	case []any:
+		values := make([]any, 0, len(value))
+		for _, item := range value {
+			values = append(values, jsonLDValue(item))
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+// item attributes of microdata and RDFa
+var itemAttributes = map[string]struct {
+	scope    string // attribute which starts an item
+	itemType string
+	property string
+}{
+	Microdata: {scope: "itemscope", itemType: "itemtype", property: "itemprop"},
+	RDFa:      {scope: "typeof", itemType: "typeof", property: "property"},
+}
+
+// collectItems returns the items of the format which aren't a property of another item,
+// an item within another one is an item of its own unless it's a property.
+func collectItems(root *html.Node, format string) []Entity {
+	attributes := itemAttributes[format]
+	entities := make([]Entity, 0)
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			_, scope := getAttribute(node, attributes.scope)
+			_, property := getAttribute(node, attributes.property)
+			if scope && !property {
+				entities = append(entities, newItem(node, format))
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return entities
+}
+
+// newItem reads the properties of the item from its descendants, nested items are properties of their own.
+func newItem(node *html.Node, format string) Entity {
+	attributes := itemAttributes[format]
+	itemType, _ := getAttribute(node, attributes.itemType)
+	entity := Entity{Format: format, Type: schemaName(itemType), Properties: make(map[string]any)}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			names, hasProperty := getAttribute(child, attributes.property)
+			_, scope := getAttribute(child, attributes.scope)
+
+			var value any
+			switch {
+			case hasProperty && scope:
+				value = newItem(child, format)
+			case hasProperty:
+				value = itemValue(child)
+			case scope:
+				// an item of its own inside this one, its properties aren't ours
+				continue
+			}
+
+			if hasProperty {
+				for _, name := range strings.Fields(names) {
+					addProperty(entity.Properties, schemaName(name), value)
+				}
+			}
+
+			if !scope {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+
+	return entity
+}
+
+// element attributes which hold the value of a property
+var valueAttributes = map[string]string{
+	"a": "href", "area": "href", "link": "href",
+	"audio": "src", "embed": "src", "iframe": "src", "img": "src", "source": "src", "track": "src", "video": "src",
+	"object": "data", "data": "value", "meter": "value", "time": "datetime",
+}
+
+// itemValue is the value of a property which isn't an item, content and RDFa's resource win over the element's own attribute.
+func itemValue(node *html.Node) string {
+	for _, key := range []string{"content", "resource", valueAttributes[node.Data]} {
+		if value, ok := getAttribute(node, key); ok {
+			return value
+		}
+	}
+
+	return getText(node)
+}
+
+func addProperty(properties map[string]any, name string, value any) {
+	switch existing := properties[name].(type) {
+	case nil:
+		properties[name] = value
+	case []any:
+		properties[name] = append(existing, value)
+	default:
+		properties[name] = []any{existing, value}
+	}
+}
+
+// schemaName drops the vocabulary, "https://schema.org/Product" and "schema:Product" are both "Product".
+// Only the first of several types is kept.
+func schemaName(name string) string {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	name = strings.TrimRight(fields[0], "/")
+	if i := strings.LastIndexAny(name, "/#:"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
+
+// validateEntity checks the required properties of the common types.
+func validateEntity(entity Entity) []Finding {
+	findings := make([]Finding, 0)
+
+	for _, alternatives := range requiredProperties[entity.Type] {
+		found := false
+		for _, name := range alternatives {
+			found = found || hasValue(entity.Properties[name])
+		}
+		if found {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Rule:     RuleMissingProperty,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s %s needs %s", entity.Format, entity.Type, strings.Join(alternatives, " or ")),
+		})
+	}
+
+	if entity.Type == "BreadcrumbList" {
+		items, ok := entity.Properties["itemListElement"].([]any)
+		if !ok && entity.Properties["itemListElement"] != nil {
+			items = []any{entity.Properties["itemListElement"]}
+		}
+
+		for i, item := range items {
+			listItem, ok := item.(Entity)
+			if ok && hasValue(listItem.Properties["position"]) &&
+				(hasValue(listItem.Properties["name"]) || hasValue(listItem.Properties["item"])) {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Rule:     RuleInvalidBreadcrumbItem,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s BreadcrumbList item %d needs a position and a name or item", entity.Format, i+1),
+			})
+		}
+	}
+
+	return findings
+}
+
+func hasValue(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(value) != ""
+	case []any:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestExtractStructuredData(t *testing.T) {
+	file, err := os.ReadFile("./testdata/extract_structured_data.html")
+	assert.NoError(t, err)
+
+	root, err := html.Parse(strings.NewReader(string(file)))
+	assert.NoError(t, err)
+
+	result, err := ExtractStructuredData(context.Background(), root, &Page{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Entity{
+		{Format: JSONLD, Type: "Product", Properties: map[string]any{
+			"name":   "Analyzer  Pro",
+			"offers": Entity{Format: JSONLD, Type: "Offer", Properties: map[string]any{"price": 9.99, "priceCurrency": "USD"}},
+		}},
+		{Format: JSONLD, Type: "Organization", Properties: map[string]any{"name": "Example"}},
+		{Format: JSONLD, Type: "BreadcrumbList", Properties: map[string]any{
+			"itemListElement": []any{
+				Entity{Format: JSONLD, Type: "ListItem", Properties: map[string]any{"position": float64(1), "name": "Home", "item": "https://example.com"}},
+				Entity{Format: JSONLD, Type: "ListItem", Properties: map[string]any{"name": "Tools"}},
+			},
+		}},
+		{Format: Microdata, Type: "Article", Properties: map[string]any{
+			"headline":      "Analyzing pages",
+			"author":        Entity{Format: Microdata, Type: "Person", Properties: map[string]any{"name": "Jane"}},
+			"datePublished": "2024-01-02",
+			"keywords":      []any{"/tags/go", "/tags/html"},
+		}},
+		{Format: Microdata, Type: "Comment", Properties: map[string]any{"text": "Not a property of the article"}},
+		{Format: RDFa, Type: "Product", Properties: map[string]any{"name": "Widget", "image": "/widget.png"}},
+	}, result.Entities)
+
+	rules := make([]string, 0)
+	for _, finding := range result.Findings {
+		rules = append(rules, finding.Rule)
+	}
+	assert.Equal(t, []string{
+		RuleInvalidJSONLD,         // the third script
+		RuleMissingProperty,       // the Organization has no url
+		RuleInvalidBreadcrumbItem, // Tools has no position
+		RuleMissingProperty,       // the RDFa Product has no offers
+	}, rules)
+	assert.Contains(t, result.Findings[0].Message, "JSON-LD script 3 isn't valid JSON")
+	assert.Equal(t, "json-ld Organization needs url", result.Findings[1].Message)
+	assert.Equal(t, "rdfa Product needs offers or review or aggregateRating", result.Findings[3].Message)
+}
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "Product", schemaName("https://schema.org/Product"))
+	assert.Equal(t, "Product", schemaName("http://schema.org/Product/"))
+	assert.Equal(t, "Product", schemaName("schema:Product"))
+	assert.Equal(t, "Product", schemaName("Product Thing"))
+	assert.Equal(t, "", schemaName(" "))
+}
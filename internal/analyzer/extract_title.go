@@ -1,33 +1,26 @@
 package analyzer
 
 import (
-	"log/slog"
-	"sync"
-	"time"
+	"context"
 
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
 	"golang.org/x/net/html"
 )
 
-func ExtractTitle(root *html.Node, wg *sync.WaitGroup, resultChan chan AnalyzerResponse) {
-	start := time.Now()
-	status := "Success"
-	functionName := "ExtractTitle"
-	defer wg.Done()
-
-	title := getTitle(root)
-	resultChan <- AnalyzerResponse{PageTitle: title}
-
-	duration := time.Since(start).Nanoseconds()
-	analyzerLogger.Info("Function Executed",
-		slog.String("function", functionName),
-		slog.Int64("duration", duration),
-	)
-
-	observability.
-		DurationMetrics.
-		WithLabelValues(functionName, status).
-		Observe(float64(duration))
+const TitleAnalysis = "title"
+
+// TitleResult is the page title.
+type TitleResult string
+
+func init() {
+	MustRegister(NewExtractor(TitleAnalysis, ExtractTitle))
+}
+
+func ExtractTitle(ctx context.Context, root *html.Node, page *Page) (TitleResult, error) {
+	return TitleResult(getTitle(root)), nil
+}
+
+func (t TitleResult) Apply(response *AnalyzerResponse) {
+	response.PageTitle = string(t)
 }
 
 func getTitle(node *html.Node) string {
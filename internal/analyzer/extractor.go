@@ -0,0 +1,242 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+	"golang.org/x/net/html"
+)
+
+// Page carries the context of the fetched page to every extractor.
+type Page struct {
+	Url         *url.URL     // url the page was fetched from
+	Body        []byte       // response body, decoded to UTF-8
+	ContentType string       // content type the page was served with, empty when it isn't known
+	Header      http.Header  // response headers, only the content type is there for HTML which wasn't fetched
+	Client      *http.Client // shared client for outbound requests such as link checks
+	LinkChecker *LinkChecker // checks the accessibility of links
+}
+
+// Extractor is a single analysis which runs against the parsed page.
+// Extractors run concurrently, so they must not modify the node tree.
+type Extractor interface {
+	// Name identifies the extractor in the response, logs and metrics.
+	Name() string
+	// Extract runs the analysis and returns its result.
+	Extract(ctx context.Context, root *html.Node, page *Page) (any, error)
+}
+
+// Applier is implemented by extractor results which know where they belong in the response.
+// Results that don't implement it are kept in AnalyzerResponse.Extensions under the extractor name.
+type Applier interface {
+	Apply(response *AnalyzerResponse)
+}
+
+type extractorFunc[T any] struct {
+	name string
+	fn   func(ctx context.Context, root *html.Node, page *Page) (T, error)
+}
+
+func (e extractorFunc[T]) Name() string {
+	return e.name
+}
+
+func (e extractorFunc[T]) Extract(ctx context.Context, root *html.Node, page *Page) (any, error) {
+	return e.fn(ctx, root, page)
+}
+
+// NewExtractor wraps a typed extract function as an Extractor.
+func NewExtractor[T any](name string, fn func(ctx context.Context, root *html.Node, page *Page) (T, error)) Extractor {
+	return extractorFunc[T]{name: name, fn: fn}
+}
+
+// Registry keeps the extractors in the order they were registered.
+type Registry struct {
+	mu         sync.RWMutex
+	extractors []Extractor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(extractor Extractor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.extractors {
+		if e.Name() == extractor.Name() {
+			return fmt.Errorf("extractor %q is already registered", extractor.Name())
+		}
+	}
+
+	r.extractors = append(r.extractors, extractor)
+	return nil
+}
+
+// Extractors returns a copy of the registered extractors.
+func (r *Registry) Extractors() []Extractor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	extractors := make([]Extractor, len(r.extractors))
+	copy(extractors, r.extractors)
+	return extractors
+}
+
+// built-in extractors register themselves here, custom ones can be added with Register.
+var defaultRegistry = NewRegistry()
+
+func Register(extractor Extractor) error {
+	return defaultRegistry.Register(extractor)
+}
+
+func MustRegister(extractor Extractor) {
+	if err := Register(extractor); err != nil {
+		panic(err)
+	}
+}
+
+type extractorResult struct {
+	name   string
+	result any
+	err    error
+}
+
+// runExtractor runs the extractor while logging and recording its duration.
+func runExtractor(ctx context.Context, log *slog.Logger, extractor Extractor, root *html.Node, page *Page) extractorResult {
+	start := time.Now()
+	status := "Success"
+
+	// don't start the extractor if the analysis has already timed out
+	var result any
+	err := ctx.Err()
+	if err == nil {
+		result, err = extractor.Extract(ctx, root, page)
+	}
+
+	switch {
+	case isTimeout(err):
+		status = "Timeout"
+	case err != nil:
+		status = "Fail"
+	}
+
+	duration := time.Since(start).Nanoseconds()
+	log.Info("Function Executed",
+		slog.String("function", extractor.Name()),
+		slog.String("status", status),
+		slog.Int64("duration", duration),
+	)
+
+	observability.
+		DurationMetrics.
+		WithLabelValues(extractor.Name(), status).
+		Observe(float64(duration))
+
+	return extractorResult{name: extractor.Name(), result: result, err: err}
+}
+
+// apply merges the result into the response.
+// An extractor which timed out can still hand back a partial result along with the context error.
+func (r extractorResult) apply(response *AnalyzerResponse) {
+	if isTimeout(r.err) {
+		response.TimedOut = append(response.TimedOut, r.name)
+		if r.result == nil {
+			return
+		}
+	} else if r.err != nil {
+		response.Errors = append(response.Errors, r.err.Error())
+		return
+	}
+
+	if applier, ok := r.result.(Applier); ok {
+		applier.Apply(response)
+		return
+	}
+
+	if response.Extensions == nil {
+		response.Extensions = make(map[string]any)
+	}
+	response.Extensions[r.name] = r.result
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// ErrUnknownAnalysis is returned when a request names an analysis which isn't registered.
+var ErrUnknownAnalysis = errors.New("unknown analysis")
+
+// selectExtractors keeps the requested extractors, all of them when none are requested, minus the excluded ones.
+func selectExtractors(extractors []Extractor, analyses, exclude []string) ([]Extractor, error) {
+	registered := make(map[string]bool, len(extractors))
+	for _, e := range extractors {
+		registered[e.Name()] = true
+	}
+
+	for _, name := range append(append([]string{}, analyses...), exclude...) {
+		if !registered[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAnalysis, name)
+		}
+	}
+
+	selected := make([]Extractor, 0, len(extractors))
+	for _, e := range extractors {
+		if len(analyses) > 0 && !slices.Contains(analyses, e.Name()) {
+			continue
+		}
+		if slices.Contains(exclude, e.Name()) {
+			continue
+		}
+		selected = append(selected, e)
+	}
+
+	return selected, nil
+}
+
+// Progress is reported every time an analysis finishes and while the links are checked.
+type Progress struct {
+	Analysis     string            // the analysis which made progress
+	Result       *AnalyzerResponse // snapshot of the response once the analysis finished, nil before
+	Link         *Link             // the link which was just checked
+	LinksChecked int               // links checked so far
+	LinksTotal   int               // links of the page
+}
+
+type ProgressFunc func(Progress)
+
+var progressKey = "analyzer-progress"
+
+// WithProgress makes the analyses run with the context report their progress to fn.
+// fn can be called from several goroutines at once.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey, fn)
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+	if fn, ok := ctx.Value(progressKey).(ProgressFunc); ok {
+		return fn
+	}
+
+	return func(Progress) {}
+}
+
+// snapshot copies the response so it can be handed out while the analysis carries on.
+func (r *AnalyzerResponse) snapshot() *AnalyzerResponse {
+	snapshot := *r
+	snapshot.Analyses = slices.Clone(r.Analyses)
+	snapshot.TimedOut = slices.Clone(r.TimedOut)
+	snapshot.Errors = slices.Clone(r.Errors)
+	snapshot.Extensions = maps.Clone(r.Extensions)
+	return &snapshot
+}
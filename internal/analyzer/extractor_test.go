@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+
+	assert.NoError(t, registry.Register(NewExtractor(TitleAnalysis, ExtractTitle)))
+	assert.NoError(t, registry.Register(NewExtractor(HeadingsAnalysis, ExtractHeadings)))
+	assert.Error(t, registry.Register(NewExtractor(TitleAnalysis, ExtractTitle)))
+
+	extractors := registry.Extractors()
+	assert.Len(t, extractors, 2)
+	assert.Equal(t, TitleAnalysis, extractors[0].Name())
+	assert.Equal(t, HeadingsAnalysis, extractors[1].Name())
+}
+
+func TestRegistry_BuiltInExtractors(t *testing.T) {
+	names := make([]string, 0)
+	for _, extractor := range defaultRegistry.Extractors() {
+		names = append(names, extractor.Name())
+	}
+
+	assert.ElementsMatch(t, []string{
+		HtmlVersionAnalysis,
+		TitleAnalysis,
+		HeadingsAnalysis,
+		LinksAnalysis,
+		LoginFormAnalysis,
+		SEOAnalysis,
+		StructuredDataAnalysis,
+		AccessibilityAnalysis,
+	}, names)
+}
+
+func TestRunExtractor(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	root, err := html.Parse(strings.NewReader("<html><head><title>Custom</title></head><body><img><img></body></html>"))
+	assert.NoError(t, err)
+
+	t.Run("Built-in result is applied to the response", func(t *testing.T) {
+		response := AnalyzerResponse{}
+		res := runExtractor(context.Background(), log, NewExtractor(TitleAnalysis, ExtractTitle), root, &Page{})
+		res.apply(&response)
+
+		assert.Equal(t, "Custom", response.PageTitle)
+		assert.Empty(t, response.Extensions)
+	})
+
+	t.Run("Custom result is kept in the extensions", func(t *testing.T) {
+		images := NewExtractor("images", func(ctx context.Context, root *html.Node, page *Page) (int, error) {
+			nodes := make([]html.Node, 0)
+			getMatchingNodes(root, &nodes, "img")
+			return len(nodes), nil
+		})
+
+		response := AnalyzerResponse{}
+		runExtractor(context.Background(), log, images, root, &Page{}).apply(&response)
+
+		assert.Equal(t, 2, response.Extensions["images"])
+	})
+
+	t.Run("Errors are collected in the response", func(t *testing.T) {
+		failing := NewExtractor("failing", func(ctx context.Context, root *html.Node, page *Page) (any, error) {
+			return nil, errors.New("something failed")
+		})
+
+		response := AnalyzerResponse{}
+		runExtractor(context.Background(), log, failing, root, &Page{}).apply(&response)
+
+		assert.Equal(t, []string{"something failed"}, response.Errors)
+		assert.Empty(t, response.Extensions)
+	})
+}
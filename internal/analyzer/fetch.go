@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
+)
+
+// fetchPage gets the page and returns its body and response headers when it's an HTML page.
+func (a *Analyzer) fetchPage(ctx context.Context, analyzerLogger *slog.Logger, pageUrl string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl, nil)
+	if err != nil {
+		analyzerLogger.Error("Invalid request", slog.String("url", pageUrl), slog.Any("error", err))
+		return nil, nil, err
+	}
+
+	// the body is decompressed here rather than in the transport, so the decompression ratio can be checked
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := a.pageClient.Do(req)
+	if err != nil {
+		analyzerLogger.Error("Error on reach the URL", slog.String("url", pageUrl), slog.Any("error", err))
+		return nil, nil, a.fetchError(err, pageUrl)
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		analyzerLogger.Error("Error Accessing URL", slog.String("url", pageUrl), slog.Int("status", resp.StatusCode))
+		return nil, nil, apperrors.NewAppError(
+			resp.StatusCode,
+			fmt.Sprintf("Error on Accessing URL: %s", pageUrl),
+		)
+	}
+
+	analyzerLogger.Debug("Response", slog.Any("response", resp))
+
+	// check for html content type
+	if !isHTML(resp.Header.Get("Content-Type")) {
+		err := fmt.Errorf("Invalid response: %s", resp.Header.Get("Content-Type"))
+		analyzerLogger.Error(err.Error(), slog.String("content-type", resp.Header.Get("Content-Type")))
+		return nil, nil, err
+	}
+
+	body, err := a.readBody(resp, pageUrl)
+	if err != nil {
+		analyzerLogger.Error("Failed to read response body", slog.Any("error", err))
+		return nil, nil, err
+	}
+
+	return body, resp.Header, nil
+}
+
+// readBody reads the body within the size and decompression limits.
+func (a *Analyzer) readBody(resp *http.Response, pageUrl string) ([]byte, error) {
+	// no need to read what's announced to be too large
+	if a.maxBodySize > 0 && resp.ContentLength > a.maxBodySize {
+		return nil, limitError(LimitBodySize, http.StatusRequestEntityTooLarge,
+			"Page is larger than %d bytes: %s", a.maxBodySize, pageUrl)
+	}
+
+	body := &limitedBody{
+		r:        resp.Body,
+		maxSize:  a.maxBodySize,
+		maxRatio: a.maxDecompressionRatio,
+		pageUrl:  pageUrl,
+	}
+
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		compressed := &countingReader{r: resp.Body}
+		reader, err := gzip.NewReader(compressed)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		body.r = reader
+		body.compressed = compressed
+	}
+
+	return io.ReadAll(body)
+}
+
+// fetchError tells the limits hit by the client apart from the other errors.
+func (a *Analyzer) fetchError(err error, pageUrl string) error {
+	if errors.Is(err, ErrTooManyRedirects) {
+		return limitError(LimitRedirects, http.StatusBadGateway,
+			"Page redirected more than %d times: %s", a.maxRedirects, pageUrl)
+	}
+
+	// the transport doesn't export this error
+	if strings.Contains(err.Error(), "server response headers exceeded") {
+		return limitError(LimitHeaderSize, http.StatusBadGateway,
+			"Page headers are larger than %d bytes: %s", a.maxHeaderSize, pageUrl)
+	}
+
+	return err
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Finding severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is an issue an analysis found on the page.
+type Finding struct {
+	Rule     string // what was checked, "missing-h1" for instance
+	Severity string // error, warning or info
+	Message  string
+	Path     string // CSS like path to the element, when the finding is about one
+}
+
+// cssPath is a CSS like path to the element, "html > body > ul > li:nth-of-type(2) > a#home" for instance.
+func cssPath(node *html.Node) string {
+	parts := make([]string, 0)
+	for ; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		part := node.Data
+		if id, _ := getAttribute(node, "id"); strings.TrimSpace(id) != "" {
+			part += "#" + strings.TrimSpace(id)
+		}
+
+		// the position only tells elements apart when there are others of the same tag
+		index, count := 1, 1
+		for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+			if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+				index++
+				count++
+			}
+		}
+		for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+			if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+				count++
+			}
+		}
+		if count > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+
+		parts = append(parts, part)
+	}
+
+	slices.Reverse(parts)
+	return strings.Join(parts, " > ")
+}
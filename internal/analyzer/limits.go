@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+)
+
+// Limits of the page fetch
+const (
+	LimitBodySize           = "body_size"
+	LimitHeaderSize         = "header_size"
+	LimitRedirects          = "redirects"
+	LimitDecompressionRatio = "decompression_ratio"
+)
+
+// small pages compress well anyway, the ratio is only checked once this much is decompressed
+const decompressionRatioThreshold = 1 << 20
+
+// limitError counts the limit on /metrics and returns its error.
+func limitError(limit string, code int, format string, args ...any) *apperrors.LimitError {
+	observability.FetchLimitsExceeded.WithLabelValues(limit).Inc()
+	return apperrors.NewLimitError(limit, code, fmt.Sprintf(format, args...))
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// limitedBody stops reading the body once it goes over the size or the decompression ratio.
+type limitedBody struct {
+	r          io.Reader
+	compressed *countingReader // bytes read off the wire, nil when the body isn't compressed
+	read       int64
+	maxSize    int64   // 0 means no limit
+	maxRatio   float64 // 0 means no limit
+	pageUrl    string
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+
+	if b.maxSize > 0 && b.read > b.maxSize {
+		return n, limitError(LimitBodySize, http.StatusRequestEntityTooLarge,
+			"Page is larger than %d bytes: %s", b.maxSize, b.pageUrl)
+	}
+
+	if b.compressed != nil && b.maxRatio > 0 && b.read > decompressionRatioThreshold &&
+		float64(b.read) > float64(b.compressed.n)*b.maxRatio {
+		return n, limitError(LimitDecompressionRatio, http.StatusRequestEntityTooLarge,
+			"Page decompresses more than %g times its size: %s", b.maxRatio, b.pageUrl)
+	}
+
+	return n, err
+}
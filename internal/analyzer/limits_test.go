@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "github.com/Jawadh-Salih/go-web-analyzer/errors"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(content []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write(content)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestAnalyzer_FetchLimits(t *testing.T) {
+	page := []byte(`<!DOCTYPE html><html><head><title>Limits</title></head><body><h1>Limits</h1></body></html>`)
+	large := []byte("<html><body>" + strings.Repeat("<p>large</p>", 1000) + "</body></html>")
+	bomb := gzipped(append([]byte("<html><body>"), make([]byte, 4<<20)...))
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/large":
+			w.Write(large)
+		case "/stream":
+			// no Content-Length, the size is only known once it's read
+			for range 100 {
+				w.Write(large[:100])
+				w.(http.Flusher).Flush()
+			}
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped(page))
+		case "/bomb":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(bomb)
+		case "/headers":
+			w.Header().Set("X-Large", strings.Repeat("a", 2048))
+			w.Write(page)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.Write(page)
+		}
+	}))
+	defer testServer.Close()
+
+	config := DefaultConfig()
+	config.MaxBodySize = 1024
+	config.MaxHeaderSize = 1024
+	config.MaxRedirects = 2
+	a, err := New(config)
+	assert.NoError(t, err)
+
+	// the bomb is small enough once decompressed, only its ratio gives it away
+	bombConfig := DefaultConfig()
+	bombConfig.MaxBodySize = 10 << 20
+	bombAnalyzer, err := New(bombConfig)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		analyzer *Analyzer
+		path     string
+		limit    string
+		code     int
+	}{
+		{"Within the limits", a, "/", "", 0},
+		{"Compressed page", a, "/gzip", "", 0},
+		{"Announced body size", a, "/large", LimitBodySize, http.StatusRequestEntityTooLarge},
+		{"Streamed body size", a, "/stream", LimitBodySize, http.StatusRequestEntityTooLarge},
+		{"Decompression ratio", bombAnalyzer, "/bomb", LimitDecompressionRatio, http.StatusRequestEntityTooLarge},
+		{"Header size", a, "/headers", LimitHeaderSize, http.StatusBadGateway},
+		{"Redirects", a, "/loop", LimitRedirects, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded := observability.FetchLimitsExceeded.WithLabelValues(tt.limit)
+			before := testutil.ToFloat64(exceeded)
+
+			response, err := tt.analyzer.Analyze(context.Background(), AnalyzerRequest{
+				Url:      testServer.URL + tt.path,
+				Analyses: []string{TitleAnalysis},
+			})
+
+			if tt.limit == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "Limits", response.PageTitle)
+				return
+			}
+
+			var limitErr *apperrors.LimitError
+			assert.True(t, errors.As(err, &limitErr), err)
+			assert.Equal(t, tt.limit, limitErr.Limit)
+			assert.Equal(t, tt.code, limitErr.StatusCode())
+			assert.Equal(t, before+1, testutil.ToFloat64(exceeded))
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package analyzer
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LinkCache keeps link check results between analyses, keyed by the normalized link url.
+type LinkCache interface {
+	Get(key string) (LinkStatus, bool)
+	Set(key string, status LinkStatus)
+}
+
+type cacheEntry struct {
+	Key       string
+	Status    LinkStatus
+	ExpiresAt time.Time
+}
+
+// MemoryCache is an in-process LRU cache with a TTL on every entry.
+type MemoryCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // most recently used at the front
+}
+
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (LinkStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return LinkStatus{}, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return LinkStatus{}, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.Status, true
+}
+
+func (c *MemoryCache) Set(key string, status LinkStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		element.Value = &cacheEntry{Key: key, Status: status, ExpiresAt: expiresAt}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{Key: key, Status: status, ExpiresAt: expiresAt})
+
+	// evict the least recently used entries over the bound
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// FileCache keeps the link check results in a JSON file so they survive restarts.
+// It's meant for local use, every Set rewrites the whole file.
+type FileCache struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func NewFileCache(path string, ttl time.Duration) (*FileCache, error) {
+	c := &FileCache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read link cache file: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c.entries); err != nil {
+			return nil, fmt.Errorf("invalid link cache file: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *FileCache) Get(key string) (LinkStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		return LinkStatus{}, false
+	}
+
+	return entry.Status, true
+}
+
+func (c *FileCache) Set(key string, status LinkStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries[key] = cacheEntry{Key: key, Status: status, ExpiresAt: now.Add(c.ttl)}
+	for k, entry := range c.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	// the cache is best effort, a failed write only costs a re-check later
+	_ = c.save()
+}
+
+func (c *FileCache) save() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a half written cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
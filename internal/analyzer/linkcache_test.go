@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Run("Entries expire after the TTL", func(t *testing.T) {
+		cache := NewMemoryCache(50*time.Millisecond, 10)
+		cache.Set("http://example.com/", LinkStatus{Category: LinkOK})
+
+		status, ok := cache.Get("http://example.com/")
+		assert.True(t, ok)
+		assert.Equal(t, LinkOK, status.Category)
+
+		time.Sleep(100 * time.Millisecond)
+		_, ok = cache.Get("http://example.com/")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Least recently used entries are evicted", func(t *testing.T) {
+		cache := NewMemoryCache(time.Minute, 2)
+		cache.Set("a", LinkStatus{})
+		cache.Set("b", LinkStatus{})
+		cache.Get("a")
+		cache.Set("c", LinkStatus{})
+
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		_, ok = cache.Get("a")
+		assert.True(t, ok)
+		_, ok = cache.Get("c")
+		assert.True(t, ok)
+		assert.Equal(t, 2, cache.Len())
+	})
+}
+
+func TestFileCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+
+	cache, err := NewFileCache(path, time.Minute)
+	assert.NoError(t, err)
+	cache.Set("http://example.com/", LinkStatus{Category: LinkClientError, StatusCode: http.StatusNotFound})
+
+	// a new cache on the same file sees the stored results
+	reloaded, err := NewFileCache(path, time.Minute)
+	assert.NoError(t, err)
+
+	status, ok := reloaded.Get("http://example.com/")
+	assert.True(t, ok)
+	assert.Equal(t, LinkClientError, status.Category)
+	assert.Equal(t, http.StatusNotFound, status.StatusCode)
+}
+
+func TestNormalizeURL(t *testing.T) {
+	urls := []struct {
+		url        string
+		normalized string
+	}{
+		{"HTTP://Example.COM", "http://example.com/"},
+		{"http://example.com:80/path#section", "http://example.com/path"},
+		{"https://example.com:443/path?q=1", "https://example.com/path?q=1"},
+		{"https://example.com:8443/", "https://example.com:8443/"},
+		{"http://[::1]:80/", "http://[::1]/"},
+	}
+
+	for _, u := range urls {
+		t.Run(u.url, func(t *testing.T) {
+			assert.Equal(t, u.normalized, normalizeURL(u.url))
+		})
+	}
+}
+
+func TestLinkChecker_Cache(t *testing.T) {
+	var requests atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer testServer.Close()
+
+	checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{Cache: NewMemoryCache(time.Minute, 10)})
+	hits := testutil.ToFloat64(observability.LinkCacheHits)
+	misses := testutil.ToFloat64(observability.LinkCacheMisses)
+
+	first := checker.Check(context.Background(), testServer.URL+"/page")
+	second := checker.Check(context.Background(), testServer.URL+"/page#footer")
+
+	assert.Equal(t, int32(1), requests.Load())
+	assert.False(t, first.Cached)
+	assert.True(t, second.Cached)
+	assert.Equal(t, first.Category, second.Category)
+	assert.Equal(t, hits+1, testutil.ToFloat64(observability.LinkCacheHits))
+	assert.Equal(t, misses+1, testutil.ToFloat64(observability.LinkCacheMisses))
+}
+
+func TestLinkChecker_TransientFailuresAreNotCached(t *testing.T) {
+	var requests atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{Cache: NewMemoryCache(time.Minute, 10)})
+
+	assert.Equal(t, LinkServerError, checker.Check(context.Background(), testServer.URL+"/unavailable").Category)
+	assert.False(t, checker.Check(context.Background(), testServer.URL+"/unavailable").Cached)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// a missing page won't come back on its own
+	checker.Check(context.Background(), testServer.URL+"/missing")
+	assert.True(t, checker.Check(context.Background(), testServer.URL+"/missing").Cached)
+	assert.Equal(t, int32(3), requests.Load())
+}
@@ -0,0 +1,297 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+)
+
+// Categories of a link check
+const (
+	LinkOK                = "ok"
+	LinkRedirect          = "redirect" // a redirect which wasn't followed
+	LinkClientError       = "http_4xx"
+	LinkServerError       = "http_5xx"
+	LinkDNSError          = "dns"
+	LinkTLSError          = "tls"
+	LinkTimeout           = "timeout"
+	LinkConnectionRefused = "connection_refused"
+	LinkConnectionError   = "connection"
+	LinkTooManyRedirects  = "too_many_redirects"
+	LinkInvalid           = "invalid"
+	LinkDisallowed        = "robots_disallowed" // not checked because robots.txt disallows it
+	LinkBlocked           = "blocked"           // not checked because the egress policy blocks it
+	LinkUnresolved        = "unresolved"        // not checked because it's relative and there's no base url to resolve it
+)
+
+type LinkStatus struct {
+	StatusCode    int           // final status code, 0 when there was no response
+	RedirectChain []string      // urls the link redirected to, the last one is the final url
+	Category      string        // one of the link check categories
+	Error         string        // error of the check if any
+	ResponseTime  time.Duration // time taken by the check
+	Method        string        // HEAD, or GET when the server doesn't allow HEAD
+	Cached        bool          // true when the result came from the link cache
+}
+
+// LinkChecker checks whether the links of a page are accessible.
+type LinkChecker struct {
+	client         *http.Client
+	cache          LinkCache // optional
+	limiter        *hostLimiter
+	requestLimiter *hostLimiter // limits of a single analysis on top of the shared ones, optional
+	maxRetryAfter  time.Duration
+	robots         *RobotsPolicy // optional
+}
+
+type LinkCheckerOptions struct {
+	Cache         LinkCache        // keeps the results between analyses, optional
+	RateLimit     RateLimitOptions // limits of the checks per host
+	MaxRetryAfter time.Duration    // longest Retry-After which is waited for before checking again
+	Robots        *RobotsPolicy    // skips the links robots.txt disallows, optional
+}
+
+func NewLinkChecker(client *http.Client, options LinkCheckerOptions) *LinkChecker {
+	return &LinkChecker{
+		client:        client,
+		cache:         options.Cache,
+		limiter:       newHostLimiter(options.RateLimit),
+		maxRetryAfter: options.MaxRetryAfter,
+		robots:        options.Robots,
+	}
+}
+
+// WithRateLimit returns a checker which also applies the given limits.
+// The shared limits, cache and client are kept so concurrent analyses still count against them.
+func (c *LinkChecker) WithRateLimit(options RateLimitOptions) *LinkChecker {
+	checker := *c
+	checker.requestLimiter = newHostLimiter(options)
+	return &checker
+}
+
+// Check returns the cached result of the link or checks it when there's none.
+func (c *LinkChecker) Check(ctx context.Context, linkUrl string) LinkStatus {
+	if c.robots != nil && !c.robots.Allowed(ctx, linkUrl) {
+		return LinkStatus{Category: LinkDisallowed}
+	}
+
+	if c.cache == nil {
+		return c.check(ctx, linkUrl)
+	}
+
+	key := normalizeURL(linkUrl)
+	if status, ok := c.cache.Get(key); ok {
+		observability.LinkCacheHits.Inc()
+		status.Cached = true
+		return status
+	}
+	observability.LinkCacheMisses.Inc()
+
+	status := c.check(ctx, linkUrl)
+
+	// a check cut short by the cancellation says nothing about the link
+	// and a transient failure is likely to be fine later
+	if ctx.Err() == nil && !status.transient() {
+		c.cache.Set(key, status)
+	}
+
+	return status
+}
+
+// check requests the link within the host limits and checks it once more when the host asks to retry later.
+func (c *LinkChecker) check(ctx context.Context, linkUrl string) LinkStatus {
+	host := linkHost(linkUrl)
+
+	var status LinkStatus
+	for attempt := 0; attempt < 2; attempt++ {
+		var wait time.Duration
+		var retry bool
+		status, wait, retry = c.limitedCheck(ctx, host, linkUrl)
+		if !retry || wait > c.maxRetryAfter {
+			break
+		}
+
+		// every check of the host waits, not only this one
+		until := time.Now().Add(wait)
+		c.limiter.backoff(host, until)
+		if c.requestLimiter != nil {
+			c.requestLimiter.backoff(host, until)
+		}
+	}
+
+	return status
+}
+
+// limitedCheck requests the link with HEAD and falls back to GET when HEAD isn't allowed.
+// It also returns how long the host asked to wait when the link was rate limited.
+func (c *LinkChecker) limitedCheck(ctx context.Context, host, linkUrl string) (LinkStatus, time.Duration, bool) {
+	release, err := c.acquire(ctx, host)
+	if err != nil {
+		return LinkStatus{Category: errorCategory(err), Error: err.Error()}, 0, false
+	}
+	defer release()
+
+	// the time waiting for the host limits isn't part of the response time
+	start := time.Now()
+	status, resp := c.request(ctx, http.MethodHead, linkUrl)
+	if status.StatusCode == http.StatusMethodNotAllowed || status.StatusCode == http.StatusNotImplemented {
+		status, resp = c.request(ctx, http.MethodGet, linkUrl)
+	}
+	status.ResponseTime = time.Since(start)
+
+	if resp == nil {
+		return status, 0, false
+	}
+
+	wait, retry := retryAfter(resp)
+	return status, wait, retry
+}
+
+func (c *LinkChecker) acquire(ctx context.Context, host string) (func(), error) {
+	releaseRequest := func() {}
+	if c.requestLimiter != nil {
+		release, err := c.requestLimiter.wait(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		releaseRequest = release
+	}
+
+	release, err := c.limiter.wait(ctx, host)
+	if err != nil {
+		releaseRequest()
+		return nil, err
+	}
+
+	return func() {
+		release()
+		releaseRequest()
+	}, nil
+}
+
+func (c *LinkChecker) request(ctx context.Context, method, linkUrl string) (LinkStatus, *http.Response) {
+	status := LinkStatus{Method: method}
+
+	req, err := http.NewRequestWithContext(ctx, method, linkUrl, nil)
+	if err != nil {
+		status.Category = LinkInvalid
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	// relative links of HTML analyzed without a base url end up here too
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		status.Category = LinkInvalid
+		status.Error = fmt.Sprintf("unsupported link: %s", linkUrl)
+		return status, nil
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		status.Category = errorCategory(err)
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	// the body isn't needed, even for a GET
+	resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	status.RedirectChain = redirectChain(resp)
+	status.Category = statusCategory(resp.StatusCode)
+	return status, resp
+}
+
+func (s LinkStatus) Accessible() bool {
+	return s.Category == LinkOK
+}
+
+// transient tells whether the check failed in a way the next one may not, a slow or rate limited server for instance.
+func (s LinkStatus) transient() bool {
+	switch s.Category {
+	case LinkTimeout, LinkConnectionError, LinkConnectionRefused, LinkDNSError, LinkServerError:
+		return true
+	default:
+		return isRateLimited(s.StatusCode)
+	}
+}
+
+func statusCategory(code int) string {
+	switch {
+	case code >= 200 && code < 300:
+		return LinkOK
+	case code >= 300 && code < 400:
+		return LinkRedirect
+	case code >= 400 && code < 500:
+		return LinkClientError
+	default:
+		return LinkServerError
+	}
+}
+
+func errorCategory(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+
+	switch {
+	case errors.Is(err, ErrBlockedByPolicy):
+		return LinkBlocked
+	case errors.Is(err, ErrTooManyRedirects):
+		return LinkTooManyRedirects
+	case errors.As(err, &dnsErr):
+		return LinkDNSError
+	case errors.As(err, &certErr),
+		errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalidErr),
+		errors.As(err, &recordHeaderErr),
+		errors.As(err, &alertErr):
+		return LinkTLSError
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return LinkTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return LinkConnectionRefused
+	default:
+		return LinkConnectionError
+	}
+}
+
+func isRateLimited(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+func linkHost(linkUrl string) string {
+	u, err := url.Parse(linkUrl)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Host)
+}
+
+// redirectChain walks back from the final response to collect the urls the link redirected to.
+func redirectChain(resp *http.Response) []string {
+	chain := make([]string, 0)
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]string{req.URL.String()}, chain...)
+	}
+
+	return chain
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkChecker_Check(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-again", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(3 * time.Second):
+		}
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	tlsServer := httptest.NewTLSServer(mux)
+	defer tlsServer.Close()
+
+	closedServer := httptest.NewServer(mux)
+	closedServer.Close()
+
+	config := DefaultConfig()
+	// long enough for a TLS handshake under the race detector
+	config.LinkTimeout = time.Second
+	config.MaxRedirects = 3
+	_, linkClient, err := newClients(config)
+	assert.NoError(t, err)
+	checker := NewLinkChecker(linkClient, LinkCheckerOptions{})
+
+	checks := []struct {
+		name       string
+		url        string
+		category   string
+		statusCode int
+		method     string
+	}{
+		{"OK", testServer.URL + "/ok", LinkOK, http.StatusOK, http.MethodHead},
+		{"Redirected", testServer.URL + "/moved", LinkOK, http.StatusOK, http.MethodHead},
+		{"HEAD not allowed", testServer.URL + "/get-only", LinkOK, http.StatusOK, http.MethodGet},
+		{"Forbidden", testServer.URL + "/forbidden", LinkClientError, http.StatusForbidden, http.MethodHead},
+		{"Not found", testServer.URL + "/missing", LinkClientError, http.StatusNotFound, http.MethodHead},
+		{"Server error", testServer.URL + "/broken", LinkServerError, http.StatusInternalServerError, http.MethodHead},
+		{"Timeout", testServer.URL + "/slow", LinkTimeout, 0, http.MethodHead},
+		{"Redirect loop", testServer.URL + "/loop", LinkTooManyRedirects, 0, http.MethodHead},
+		{"Untrusted certificate", tlsServer.URL + "/ok", LinkTLSError, 0, http.MethodHead},
+		{"Connection refused", closedServer.URL + "/ok", LinkConnectionRefused, 0, http.MethodHead},
+		{"Unknown host", "http://unknown-host.invalid/", LinkDNSError, 0, http.MethodHead},
+		{"Invalid url", "http://%zz", LinkInvalid, 0, http.MethodHead},
+	}
+
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			status := checker.Check(context.Background(), check.url)
+
+			assert.Equal(t, check.category, status.Category, status.Error)
+			assert.Equal(t, check.statusCode, status.StatusCode)
+			assert.Equal(t, check.method, status.Method)
+			assert.Equal(t, check.category == LinkOK, status.Accessible())
+			assert.Positive(t, status.ResponseTime)
+		})
+	}
+
+	t.Run("Redirect chain is recorded", func(t *testing.T) {
+		status := checker.Check(context.Background(), testServer.URL+"/moved")
+
+		assert.Equal(t, []string{testServer.URL + "/moved-again", testServer.URL + "/ok"}, status.RedirectChain)
+	})
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitOptions limits the link checks sent to a single host.
+type RateLimitOptions struct {
+	MaxConcurrentPerHost int     `json:"max_concurrent_per_host" binding:"min=0"` // 0 means no limit
+	RequestsPerSecond    float64 `json:"requests_per_second" binding:"min=0"`     // 0 means no limit
+}
+
+// idle hosts are swept from the limiter at most this often
+const hostSweepInterval = time.Minute
+
+// hostLimiter caps the concurrency and the request rate per host.
+type hostLimiter struct {
+	mu          sync.Mutex
+	concurrency int
+	interval    time.Duration // minimum time between two requests to a host
+	hosts       map[string]*hostState
+	lastSweep   time.Time
+}
+
+type hostState struct {
+	slots chan struct{} // nil when the concurrency isn't limited
+	next  time.Time     // earliest time the next request can be sent
+	users int           // requests waiting for or holding the host, it isn't evicted while they do
+}
+
+func newHostLimiter(options RateLimitOptions) *hostLimiter {
+	var interval time.Duration
+	if options.RequestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / options.RequestsPerSecond)
+	}
+
+	return &hostLimiter{
+		concurrency: options.MaxConcurrentPerHost,
+		interval:    interval,
+		hosts:       make(map[string]*hostState),
+	}
+}
+
+// state returns the state of the host, l.mu must be held.
+func (l *hostLimiter) state(host string) *hostState {
+	state, ok := l.hosts[host]
+	if !ok {
+		l.sweep()
+
+		state = &hostState{}
+		if l.concurrency > 0 {
+			state.slots = make(chan struct{}, l.concurrency)
+		}
+		l.hosts[host] = state
+	}
+
+	return state
+}
+
+// sweep evicts the hosts nobody is using and which are free to be requested again,
+// so a long running limiter doesn't keep every host it has ever seen. l.mu must be held.
+func (l *hostLimiter) sweep() {
+	now := time.Now()
+	if now.Sub(l.lastSweep) < hostSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for host, state := range l.hosts {
+		if state.users == 0 && state.next.Before(now) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// wait blocks until a request can be sent to the host.
+// The returned release must be called once the request is done.
+func (l *hostLimiter) wait(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	state := l.state(host)
+	state.users++
+	l.mu.Unlock()
+
+	done := func() {
+		l.mu.Lock()
+		state.users--
+		l.mu.Unlock()
+	}
+
+	release := done
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+			release = func() {
+				<-state.slots
+				done()
+			}
+		case <-ctx.Done():
+			done()
+			return nil, ctx.Err()
+		}
+	}
+
+	// reserve the next free time of the host
+	l.mu.Lock()
+	now := time.Now()
+	at := state.next
+	if at.Before(now) {
+		at = now
+	}
+	state.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay := time.Until(at); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// backoff holds back every request to the host until the given time.
+func (l *hostLimiter) backoff(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.state(host)
+	if state.next.Before(until) {
+		state.next = until
+	}
+}
+
+// retryAfter reads the Retry-After header of a 429 or 503 response.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if !isRateLimited(resp.StatusCode) {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrencyServer records the highest number of requests it served at once.
+func concurrencyServer(delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	var inFlight, highest atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			h := highest.Load()
+			if current <= h || highest.CompareAndSwap(h, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+	}))
+
+	return server, &highest
+}
+
+func checkAll(checker *LinkChecker, baseUrl string, count int) []LinkStatus {
+	statuses := make([]LinkStatus, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = checker.Check(context.Background(), fmt.Sprintf("%s/%d", baseUrl, i))
+		}()
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+func TestLinkChecker_ConcurrencyPerHost(t *testing.T) {
+	testServer, highest := concurrencyServer(20 * time.Millisecond)
+	defer testServer.Close()
+
+	checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{
+		RateLimit: RateLimitOptions{MaxConcurrentPerHost: 2},
+	})
+
+	for _, status := range checkAll(checker, testServer.URL, 20) {
+		assert.Equal(t, LinkOK, status.Category)
+	}
+	assert.LessOrEqual(t, highest.Load(), int32(2))
+}
+
+func TestLinkChecker_RequestRateLimit(t *testing.T) {
+	t.Run("Shared limit", func(t *testing.T) {
+		testServer, _ := concurrencyServer(0)
+		defer testServer.Close()
+
+		checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{
+			RateLimit: RateLimitOptions{RequestsPerSecond: 20},
+		})
+
+		start := time.Now()
+		statuses := checkAll(checker, testServer.URL, 5)
+
+		// the first request goes right away, the other 4 are 50ms apart
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+		// waiting for the limit isn't part of the response time
+		for _, status := range statuses {
+			assert.Less(t, status.ResponseTime, 50*time.Millisecond)
+		}
+	})
+
+	t.Run("Limit of the request", func(t *testing.T) {
+		testServer, highest := concurrencyServer(20 * time.Millisecond)
+		defer testServer.Close()
+
+		checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{}).
+			WithRateLimit(RateLimitOptions{MaxConcurrentPerHost: 1})
+
+		checkAll(checker, testServer.URL, 10)
+		assert.Equal(t, int32(1), highest.Load())
+	})
+}
+
+func TestLinkChecker_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer testServer.Close()
+
+	t.Run("Waits and checks again", func(t *testing.T) {
+		requests.Store(0)
+		checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{MaxRetryAfter: 2 * time.Second})
+
+		start := time.Now()
+		status := checker.Check(context.Background(), testServer.URL)
+
+		assert.Equal(t, LinkOK, status.Category)
+		assert.Equal(t, int32(2), requests.Load())
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Gives up when the wait is too long", func(t *testing.T) {
+		requests.Store(0)
+		checker := NewLinkChecker(testServer.Client(), LinkCheckerOptions{MaxRetryAfter: 500 * time.Millisecond})
+
+		status := checker.Check(context.Background(), testServer.URL)
+
+		assert.Equal(t, LinkClientError, status.Category)
+		assert.Equal(t, http.StatusTooManyRequests, status.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	responses := []struct {
+		name   string
+		status int
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"Seconds", http.StatusTooManyRequests, "3", 3 * time.Second, true},
+		{"Date", http.StatusServiceUnavailable, date, time.Hour, true},
+		{"Missing header", http.StatusTooManyRequests, "", 0, false},
+		{"Invalid header", http.StatusTooManyRequests, "soon", 0, false},
+		{"Not rate limited", http.StatusOK, "3", 0, false},
+	}
+
+	for _, r := range responses {
+		t.Run(r.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: r.status, Header: http.Header{}}
+			if r.header != "" {
+				resp.Header.Set("Retry-After", r.header)
+			}
+
+			wait, ok := retryAfter(resp)
+			assert.Equal(t, r.ok, ok)
+			assert.InDelta(t, r.wait, wait, float64(time.Second))
+		})
+	}
+}
+
+func TestHostLimiter_EvictsIdleHosts(t *testing.T) {
+	limiter := newHostLimiter(RateLimitOptions{MaxConcurrentPerHost: 1})
+
+	release, err := limiter.wait(context.Background(), "idle.example")
+	assert.NoError(t, err)
+	release()
+
+	busy, err := limiter.wait(context.Background(), "busy.example")
+	assert.NoError(t, err)
+	defer busy()
+
+	// the next new host sweeps as if the interval had passed
+	limiter.lastSweep = time.Time{}
+	release, err = limiter.wait(context.Background(), "new.example")
+	assert.NoError(t, err)
+	release()
+
+	assert.NotContains(t, limiter.hosts, "idle.example")
+	assert.Contains(t, limiter.hosts, "busy.example")
+	assert.Contains(t, limiter.hosts, "new.example")
+}
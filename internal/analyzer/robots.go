@@ -0,0 +1,273 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// robots.txt files over this size are only read up to it, as RFC 9309 allows
+const maxRobotsSize = 500 * 1024
+
+// expired hosts are swept from the policy at most this often
+const robotsSweepInterval = time.Minute
+
+// RobotsPolicy fetches and caches the robots.txt of every host and tells whether a url may be requested.
+type RobotsPolicy struct {
+	mu        sync.Mutex
+	client    *http.Client
+	userAgent string // product token matched against the user-agent lines
+	ttl       time.Duration
+	hosts     map[string]*robotsEntry
+	lastSweep time.Time
+}
+
+type robotsEntry struct {
+	ready     chan struct{} // closed once the rules are fetched
+	rules     *robotsRules  // nil while fetching, set under mu before ready is closed
+	expiresAt time.Time
+}
+
+func NewRobotsPolicy(client *http.Client, userAgent string, ttl time.Duration) *RobotsPolicy {
+	// only the product token is matched, "go-web-analyzer/1.0 (+info)" becomes "go-web-analyzer"
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	return &RobotsPolicy{
+		client:    client,
+		userAgent: token,
+		ttl:       ttl,
+		hosts:     make(map[string]*robotsEntry),
+	}
+}
+
+// Allowed tells whether the url may be requested.
+func (p *RobotsPolicy) Allowed(ctx context.Context, rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return true
+	}
+
+	rules, err := p.rules(ctx, u)
+	if err != nil {
+		// the analysis got cancelled, the result won't be used anyway
+		return true
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return rules.allowed(path)
+}
+
+// rules returns the cached rules of the host, fetching them once when they're missing or expired.
+func (p *RobotsPolicy) rules(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	p.mu.Lock()
+	entry, ok := p.hosts[key]
+	if ok && entry.rules != nil && time.Now().After(entry.expiresAt) {
+		ok = false
+	}
+
+	if !ok {
+		p.sweep()
+		entry = &robotsEntry{ready: make(chan struct{})}
+		p.hosts[key] = entry
+		p.mu.Unlock()
+
+		// fetched without the analysis context so a cancelled analysis doesn't leave the host without rules
+		rules := p.fetch(key + "/robots.txt")
+
+		p.mu.Lock()
+		entry.rules = rules
+		entry.expiresAt = time.Now().Add(p.ttl)
+		p.mu.Unlock()
+
+		close(entry.ready)
+		return rules, nil
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		// the rules were set before ready was closed
+		return entry.rules, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sweep evicts the expired hosts, so a long running policy doesn't keep every host it has ever seen. p.mu must be held.
+func (p *RobotsPolicy) sweep() {
+	now := time.Now()
+	if now.Sub(p.lastSweep) < robotsSweepInterval {
+		return
+	}
+	p.lastSweep = now
+
+	for key, entry := range p.hosts {
+		// hosts still being fetched have waiters
+		if entry.rules != nil && now.After(entry.expiresAt) {
+			delete(p.hosts, key)
+		}
+	}
+}
+
+// fetch follows RFC 9309: a missing robots.txt allows everything, an unreachable one disallows everything.
+func (p *RobotsPolicy) fetch(robotsUrl string) *robotsRules {
+	resp, err := p.client.Get(robotsUrl)
+	if err != nil {
+		return disallowAll()
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), p.userAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsRules{}
+	default:
+		return disallowAll()
+	}
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsRules struct {
+	rules []robotsRule
+}
+
+func disallowAll() *robotsRules {
+	return &robotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+}
+
+// parseRobots keeps the rules of the groups of the user agent, or of the "*" group when it has none.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	type group struct {
+		agents []string
+		rules  []robotsRule
+	}
+
+	groups := make([]*group, 0)
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share the same group
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			// an empty disallow allows everything, it doesn't add a rule
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		default:
+			inAgents = false
+		}
+	}
+
+	// RFC 9309 matches the product token as a whole, "web" isn't a group of "go-web-analyzer"
+	var matched, wildcard []robotsRule
+	found := false
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			switch agent {
+			case "*":
+				wildcard = append(wildcard, g.rules...)
+			case userAgent:
+				matched = append(matched, g.rules...)
+				found = true
+			}
+		}
+	}
+
+	if found {
+		return &robotsRules{rules: matched}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// allowed applies the most specific matching rule, allow wins when they're equally specific.
+func (r *robotsRules) allowed(path string) bool {
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allowed = rule.allow
+		}
+	}
+
+	return allowed
+}
+
+// matchRobotsPattern matches the path prefix with "*" for any characters and a trailing "$" for the end.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		// the last part has to be at the end of an anchored pattern
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
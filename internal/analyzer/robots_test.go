@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const robotsTxt = `
+# comments are ignored
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: other-bot
+User-agent: go-web-analyzer
+Disallow: /no-analyzer
+
+User-agent: web
+Disallow: /web-only
+
+User-agent: Analyzer
+Disallow: /analyzer-only
+`
+
+func TestParseRobots(t *testing.T) {
+	paths := []struct {
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"some-bot", "/", true},
+		{"some-bot", "/private", false},
+		{"some-bot", "/private/page", false},
+		{"some-bot", "/private/public/page", true},
+		{"some-bot", "/files/report.pdf", false},
+		{"some-bot", "/files/report.pdf?download=1", true},
+		{"some-bot", "/no-analyzer", true},
+		// the analyzer has its own group, so the "*" rules don't apply to it
+		{"go-web-analyzer", "/private", true},
+		{"go-web-analyzer", "/no-analyzer/page", false},
+		// only the whole product token matches, "web" and "analyzer" are other bots
+		{"go-web-analyzer", "/web-only", true},
+		{"go-web-analyzer", "/analyzer-only", true},
+		{"web", "/web-only", false},
+	}
+
+	for _, p := range paths {
+		t.Run(p.userAgent+" "+p.path, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robotsTxt), p.userAgent)
+			assert.Equal(t, p.allowed, rules.allowed(p.path))
+		})
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	assert.True(t, matchRobotsPattern("/a", "/abc"))
+	assert.False(t, matchRobotsPattern("/a$", "/abc"))
+	assert.True(t, matchRobotsPattern("/a*c", "/abbbc/d"))
+	assert.True(t, matchRobotsPattern("/*.php$", "/dir/index.php"))
+	assert.False(t, matchRobotsPattern("/*.php$", "/dir/index.php5"))
+	assert.False(t, matchRobotsPattern("/b", "/abc"))
+}
+
+func TestAnalyze_RespectRobots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	var testServer *httptest.Server
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsRequests.Add(1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/", "/private":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<!DOCTYPE html><html><body><a href="/public">Public</a><a href="/private/page">Private</a></body></html>`)
+		}
+	}))
+	defer testServer.Close()
+
+	config := DefaultConfig()
+	config.RespectRobots = true
+	config.LinkCacheSize = 0
+
+	a, err := New(config)
+	assert.NoError(t, err)
+
+	t.Run("Disallowed links are reported separately", func(t *testing.T) {
+		response, err := a.Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL})
+		assert.NoError(t, err)
+
+		links := response.LinkSummary.Links
+		assert.Equal(t, LinkOK, links[0].Status.Category)
+		assert.Equal(t, LinkDisallowed, links[1].Status.Category)
+		assert.Equal(t, 1, response.LinkSummary.AccessibleLinks)
+		assert.Equal(t, 1, response.LinkSummary.DisallowedLinks)
+		assert.Equal(t, 0, response.LinkSummary.InaccessibleLinks)
+	})
+
+	t.Run("Disallowed page is not fetched", func(t *testing.T) {
+		response, err := a.Analyze(context.Background(), AnalyzerRequest{Url: testServer.URL + "/private"})
+		assert.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Nil(t, response)
+	})
+
+	// robots.txt is fetched once for the host
+	assert.Equal(t, int32(1), robotsRequests.Load())
+}
+
+func TestRobotsPolicy_UnavailableRobots(t *testing.T) {
+	statuses := []struct {
+		status  int
+		allowed bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusInternalServerError, false},
+	}
+
+	for _, s := range statuses {
+		t.Run(http.StatusText(s.status), func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(s.status)
+			}))
+			defer testServer.Close()
+
+			policy := NewRobotsPolicy(testServer.Client(), "go-web-analyzer", time.Minute)
+			assert.Equal(t, s.allowed, policy.Allowed(context.Background(), testServer.URL+"/page"))
+		})
+	}
+}
+
+func TestRobotsPolicy_ConcurrentAllowed(t *testing.T) {
+	var robotsRequests atomic.Int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		robotsRequests.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, robotsTxt)
+	}))
+	defer testServer.Close()
+
+	policy := NewRobotsPolicy(testServer.Client(), "go-web-analyzer", time.Minute)
+
+	allowed := make([]bool, 8)
+	var wg sync.WaitGroup
+	for i := range allowed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := "/page"
+			if i%2 == 1 {
+				path = "/no-analyzer"
+			}
+			allowed[i] = policy.Allowed(context.Background(), testServer.URL+path)
+		}()
+	}
+	wg.Wait()
+
+	for i, ok := range allowed {
+		assert.Equal(t, i%2 == 0, ok)
+	}
+	assert.Equal(t, int32(1), robotsRequests.Load())
+}
+
+func TestRobotsPolicy_EvictsExpiredHosts(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, robotsTxt)
+	}))
+	defer testServer.Close()
+
+	policy := NewRobotsPolicy(testServer.Client(), "go-web-analyzer", time.Minute)
+	assert.True(t, policy.Allowed(context.Background(), testServer.URL+"/page"))
+	key := strings.ToLower(testServer.URL)
+	assert.Contains(t, policy.hosts, key)
+
+	// expire the host and let the next new host sweep
+	policy.hosts[key].expiresAt = time.Now().Add(-time.Second)
+	policy.lastSweep = time.Time{}
+	assert.True(t, policy.Allowed(context.Background(), strings.Replace(testServer.URL, "127.0.0.1", "localhost", 1)+"/page"))
+
+	assert.NotContains(t, policy.hosts, key)
+}
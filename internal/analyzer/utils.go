@@ -1,44 +1,137 @@
 package analyzer
 
 import (
+	"net"
+	"net/url"
+	"slices"
 	"strings"
 
 	"golang.org/x/net/html"
 )
 
-func detectHTMLVersion(htmlStr string) string {
-	lower := strings.ToLower(htmlStr)
-	switch {
-	case strings.Contains(lower, "<!doctype html>"):
-		return "HTML5"
-	case strings.Contains(lower, "<!doctype html public \"-//w3c//dtd html 4.01 transitional//en\""):
-		return "HTML 4.01 Transitional"
-	case strings.Contains(lower, "<!doctype html public \"-//w3c//dtd html 4.01//en\""):
-		return "HTML 4.01 Strict"
-	case strings.Contains(lower, "<!doctype html public \"-//w3c//dtd xhtml 1.0 transitional//en\""):
-		return "XHTML 1.0 Transitional"
-	case strings.Contains(lower, "<!doctype html public \"-//w3c//dtd xhtml 1.0 strict//en\""):
-		return "XHTML 1.0 Strict"
-	default:
-		return "Unknown"
+// mediaType returns the lower cased media type of the content type, without its parameters.
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// isHTML is true for the content types which are analyzed, XHTML served as XML included.
+func isHTML(contentType string) bool {
+	switch mediaType(contentType) {
+	case "text/html", "application/xhtml+xml":
+		return true
+	}
+	return false
+}
+
+// resolveUrl resolves the url of an attribute against the page, an empty one is the page itself.
+// It's left as it is when it can't be parsed.
+func resolveUrl(rawUrl string, baseUrl *url.URL) string {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return rawUrl
 	}
+
+	if baseUrl != nil {
+		u = baseUrl.ResolveReference(u)
+	}
+
+	return u.String()
 }
 
 func getMatchingNodes(node *html.Node, nodes *[]html.Node, nodesData ...string) {
-	// should check for href attribute
+	walkElements(node, func(element *html.Node) {
+		// filter only what is in the nodesData
+		if slices.Contains(nodesData, element.Data) {
+			*nodes = append(*nodes, *element)
+		}
+	})
+}
 
-	// if we can find these 2 info then
+// walkElements calls fn for every element of the tree, the node included, in document order.
+func walkElements(node *html.Node, fn func(element *html.Node)) {
 	if node.Type == html.ElementNode {
-		// for loop to filter only what is in the nodesData
-		for _, data := range nodesData {
-			if data == node.Data {
-				*nodes = append(*nodes, *node)
-			}
-		}
+		fn(node)
 	}
 
 	// recursively check for child nodes
 	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		getMatchingNodes(child, nodes, nodesData...)
+		walkElements(child, fn)
+	}
+}
+
+func getAttribute(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
 	}
+
+	return "", false
+}
+
+// getText returns the text within the node with the whitespace collapsed.
+func getText(node *html.Node) string {
+	var builder strings.Builder
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+			builder.WriteString(" ")
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// imageAlts joins the alt of the images within the node, what's read out for an image only link or heading.
+func imageAlts(node *html.Node) string {
+	images := make([]html.Node, 0)
+	getMatchingNodes(node, &images, "img")
+
+	alts := make([]string, 0, len(images))
+	for _, image := range images {
+		if alt, _ := getAttribute(&image, "alt"); strings.TrimSpace(alt) != "" {
+			alts = append(alts, strings.TrimSpace(alt))
+		}
+	}
+
+	return strings.Join(alts, " ")
+}
+
+// normalizeURL gives the same key for urls which point to the same resource.
+// The scheme and host are lower cased, default ports and fragments are dropped and an empty path becomes "/".
+func normalizeURL(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 hosts keep their brackets
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String()
 }
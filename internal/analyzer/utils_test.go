@@ -1,34 +1,59 @@
 package analyzer
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
 )
 
 func TestHtmlVersions(t *testing.T) {
-	// add tests for different content types
 	htmls := []struct {
-		content string
-		version string
+		content     string
+		contentType string
+		version     string
+		mode        string
 	}{
-		{content: "<!DOCTYPE html><html><head><title>HTML5</title></head><body></body></html>", version: "HTML5"},
-		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01//EN\" \"http://www.w3.org/TR/html4/strict.dtd\"><html><head><title>HTML 4.01</title></head><body></body></html>", version: "HTML 4.01 Strict"},
-		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\" \"http://www.w3.org/TR/html4/loose.dtd\"><html><head><title>HTML 4.01 Transitional</title></head><body></body></html>", version: "HTML 4.01 Transitional"},
-		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\"><html><head><title>XHTML 1.0 Transitional</title></head><body></body></html>", version: "XHTML 1.0 Transitional"},
-		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd\"><html><head><title>XHTML 1.0 Strict</title></head><body></body></html>", version: "XHTML 1.0 Strict"},
-		{content: "<html></title></head><body></body></html>", version: "Unknown"},
+		{content: "<!DOCTYPE html><html><head><title>HTML5</title></head><body></body></html>", version: "HTML5", mode: NoQuirksMode},
+		{content: "<!doctype   HTML  ><html></html>", version: "HTML5", mode: NoQuirksMode},
+		{content: "<!DOCTYPE html SYSTEM \"about:legacy-compat\"><html></html>", version: "HTML5", mode: NoQuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01//EN\" \"http://www.w3.org/TR/html4/strict.dtd\"><html><head><title>HTML 4.01</title></head><body></body></html>", version: "HTML 4.01 Strict", mode: NoQuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\" \"http://www.w3.org/TR/html4/loose.dtd\"><html><head><title>HTML 4.01 Transitional</title></head><body></body></html>", version: "HTML 4.01 Transitional", mode: LimitedQuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\"><html></html>", version: "HTML 4.01 Transitional", mode: QuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Frameset//EN\" \"http://www.w3.org/TR/html4/frameset.dtd\"><html></html>", version: "HTML 4.01 Frameset", mode: LimitedQuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.0 Transitional//EN\"><html></html>", version: "HTML 4.0 Transitional", mode: QuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 3.2 Final//EN\"><html></html>", version: "HTML 3.2", mode: QuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//IETF//DTD HTML 2.0//EN\"><html></html>", version: "HTML 2.0", mode: QuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\"><html><head><title>XHTML 1.0 Transitional</title></head><body></body></html>", version: "XHTML 1.0 Transitional", mode: LimitedQuirksMode},
+		{content: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd\"><html><head><title>XHTML 1.0 Strict</title></head><body></body></html>", version: "XHTML 1.0 Strict", mode: NoQuirksMode},
+		{content: "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Frameset//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd\"><html></html>", version: "XHTML 1.0 Frameset", mode: LimitedQuirksMode},
+		{content: "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.1//EN\"\n    \"http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd\"><html></html>", version: "XHTML 1.1", mode: NoQuirksMode},
+		{content: "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.1//EN\" \"http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd\"><html></html>", contentType: "application/xhtml+xml; charset=utf-8", version: "XHTML 1.1", mode: NoQuirksMode},
+		{content: "<!DOCTYPE html><html xmlns=\"http://www.w3.org/1999/xhtml\"></html>", contentType: "application/xhtml+xml", version: "XHTML5", mode: NoQuirksMode},
+		{content: "<!DOCTYPE html PUBLIC \"-//Example//DTD Custom//EN\"><html></html>", version: "Unknown", mode: NoQuirksMode},
+		{content: "<!DOCTYPE svg><html></html>", version: "Unknown", mode: QuirksMode},
+		{content: "<html></title></head><body></body></html>", version: "Unknown", mode: QuirksMode},
 	}
 
 	for _, ct := range htmls {
 		t.Run(ct.content, func(t *testing.T) {
-			version := detectHTMLVersion(ct.content)
-			assert.Equal(
-				t,
-				ct.version,
-				version,
-			)
+			root, err := html.Parse(strings.NewReader(ct.content))
+			assert.NoError(t, err)
+
+			result, err := ExtractHtmlVersion(context.Background(), root, &Page{ContentType: ct.contentType})
+			assert.NoError(t, err)
+			assert.Equal(t, ct.version, result.Version)
+			assert.Equal(t, ct.mode, result.Mode)
 		})
 	}
+}
 
+func TestIsHTML(t *testing.T) {
+	assert.True(t, isHTML("text/html"))
+	assert.True(t, isHTML("Text/HTML; charset=utf-8"))
+	assert.True(t, isHTML("application/xhtml+xml"))
+	assert.False(t, isHTML("application/json"))
+	assert.False(t, isHTML(""))
 }
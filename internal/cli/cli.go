@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+)
+
+// Exit codes of the analyze command, scripts can tell the failures apart with them.
+const (
+	ExitOK          = 0
+	ExitFailed      = 1 // the page couldn't be analyzed
+	ExitUsage       = 2 // invalid arguments
+	ExitBrokenLinks = 3 // the page has inaccessible links
+)
+
+// Analyze runs the analyze command with the arguments which follow it,
+// "analyze [flags] <url>" or "analyze -file <path> [flags]", and returns the exit code.
+func Analyze(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: go-web-analyzer analyze [flags] <url>")
+		fmt.Fprintln(stderr, "       go-web-analyzer analyze -file <path> [-base-url <url>] [flags]")
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("output", "table", "output format: table, json or yaml")
+	analyses := flags.String("analyses", "", "comma separated analyses to run, all of them when empty")
+	exclude := flags.String("exclude", "", "comma separated analyses to skip")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the whole analysis")
+	verbose := flags.Bool("verbose", false, "log the progress to stderr")
+	file := flags.String("file", "", "analyze the HTML of the file instead of a url, - reads it from stdin")
+	baseUrl := flags.String("base-url", "", "url the relative links of the file are resolved against")
+
+	if err := parseFlags(flags, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	// either a url or a file is analyzed
+	target := *file
+	if target == "" && flags.NArg() == 1 {
+		target = flags.Arg(0)
+	} else if target == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	format, ok := formats[*output]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown output format %q\n", *output)
+		return ExitUsage
+	}
+
+	// the output goes to stdout and the errors are reported below, the logs only get in the way unless asked for
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if *verbose {
+		log = slog.New(slog.NewTextHandler(stderr, nil))
+	}
+
+	ctx, cancel := context.WithTimeout(logger.SetLogger(ctx, log), *timeout)
+	defer cancel()
+
+	var result *analyzer.AnalyzerResponse
+	var err error
+	if *file == "" {
+		result, err = analyzer.Analyze(ctx, analyzer.AnalyzerRequest{
+			Url:      target,
+			Analyses: splitList(*analyses),
+			Exclude:  splitList(*exclude),
+		})
+	} else {
+		result, err = analyzeFile(ctx, *file, stdin, analyzer.HTMLRequest{
+			BaseUrl:  *baseUrl,
+			Analyses: splitList(*analyses),
+			Exclude:  splitList(*exclude),
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to analyze %s: %v\n", target, err)
+		if errors.Is(err, analyzer.ErrUnknownAnalysis) {
+			return ExitUsage
+		}
+		return ExitFailed
+	}
+
+	if err := format(stdout, target, result); err != nil {
+		fmt.Fprintf(stderr, "failed to write the result: %v\n", err)
+		return ExitFailed
+	}
+
+	if result.LinkSummary != nil && result.LinkSummary.InaccessibleLinks > 0 {
+		return ExitBrokenLinks
+	}
+
+	return ExitOK
+}
+
+// analyzeFile analyzes the HTML of the file, "-" being stdin.
+func analyzeFile(ctx context.Context, path string, stdin io.Reader, request analyzer.HTMLRequest) (*analyzer.AnalyzerResponse, error) {
+	var body []byte
+	var err error
+	if path == "-" {
+		body, err = io.ReadAll(stdin)
+	} else {
+		body, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzer.AnalyzeHTML(ctx, body, request)
+}
+
+// parseFlags allows the flags on either side of the url, "analyze <url> -output json" included.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	positional := make([]string, 0)
+	for flags.NArg() > 0 {
+		positional = append(positional, flags.Arg(0))
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return err
+		}
+	}
+
+	return flags.Parse(append([]string{"--"}, positional...))
+}
+
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Home</title></head><body><h1>Home</h1><a href="/about">About</a></body></html>`)
+		case "/broken":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Broken</title></head><body><a href="/missing">Missing</a></body></html>`)
+		case "/about":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestAnalyze(t *testing.T) {
+	server := testServer()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		code     int
+		contains []string
+	}{
+		{
+			name:     "Table output",
+			args:     []string{server.URL},
+			code:     ExitOK,
+			contains: []string{"Title", "Home", "Headings h1", "Accessible Links"},
+		},
+		{
+			name:     "JSON output with flags after the url",
+			args:     []string{server.URL, "-output", "json"},
+			code:     ExitOK,
+			contains: []string{`"PageTitle": "Home"`},
+		},
+		{
+			name:     "YAML output",
+			args:     []string{"-output", "yaml", "-analyses", "title", server.URL},
+			code:     ExitOK,
+			contains: []string{"PageTitle: Home"},
+		},
+		{
+			name:     "Broken links",
+			args:     []string{server.URL + "/broken"},
+			code:     ExitBrokenLinks,
+			contains: []string{"BROKEN LINK", server.URL + "/missing", "404", "http_4xx"},
+		},
+		{
+			name: "Failed fetch",
+			args: []string{server.URL + "/missing"},
+			code: ExitFailed,
+		},
+		{
+			name:     "File",
+			args:     []string{"-file", "testdata/page.html", "-base-url", server.URL},
+			code:     ExitOK,
+			contains: []string{"Saved Page", "Internal Links      1"},
+		},
+		{
+			name:     "File without a base url",
+			args:     []string{"-file", "testdata/page.html"},
+			code:     ExitOK,
+			contains: []string{"Saved Page", "Inaccessible Links  0", "Unchecked Links     1"},
+		},
+		{
+			name:     "Stdin with broken links",
+			args:     []string{"-file", "-", "-base-url", server.URL},
+			stdin:    `<html><head><title>Piped</title></head><body><a href="/missing">Missing</a></body></html>`,
+			code:     ExitBrokenLinks,
+			contains: []string{"Piped", server.URL + "/missing"},
+		},
+		{
+			name: "Missing file",
+			args: []string{"-file", "testdata/missing.html"},
+			code: ExitFailed,
+		},
+		{
+			name: "Both url and file",
+			args: []string{"-file", "-", server.URL},
+			code: ExitUsage,
+		},
+		{
+			name: "Missing url",
+			args: []string{},
+			code: ExitUsage,
+		},
+		{
+			name: "Unknown format",
+			args: []string{"-output", "xml", server.URL},
+			code: ExitUsage,
+		},
+		{
+			name: "Unknown analysis",
+			args: []string{"-analyses", "unknown", server.URL},
+			code: ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Analyze(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.code, code, stderr.String())
+			for _, expected := range tt.contains {
+				assert.Contains(t, stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"title", "links"}, splitList(" title, ,links"))
+	assert.Empty(t, splitList(""))
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"gopkg.in/yaml.v3"
+)
+
+type formatFunc func(w io.Writer, url string, result *analyzer.AnalyzerResponse) error
+
+var formats = map[string]formatFunc{
+	"table": writeTable,
+	"json":  writeJSON,
+	"yaml":  writeYAML,
+}
+
+func writeJSON(w io.Writer, _ string, result *analyzer.AnalyzerResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writeYAML goes through JSON so the fields are named the same as on the API.
+func writeYAML(w io.Writer, _ string, result *analyzer.AnalyzerResponse) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func writeTable(w io.Writer, url string, result *analyzer.AnalyzerResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "URL\t%s\n", url)
+	fmt.Fprintf(tw, "HTML Version\t%s\n", result.HtmlVersion)
+	fmt.Fprintf(tw, "Document Mode\t%s\n", result.DocumentMode)
+	fmt.Fprintf(tw, "Title\t%s\n", result.PageTitle)
+	fmt.Fprintf(tw, "Encoding\t%s\n", result.Encoding)
+	fmt.Fprintf(tw, "Login Form\t%s\n", yesNo(result.HasLoginForm))
+	for _, form := range result.AuthForms {
+		fmt.Fprintf(tw, "Auth Form\t%s %s %s\n", form.Type, form.Method, form.Action)
+	}
+	for _, button := range result.SSOButtons {
+		fmt.Fprintf(tw, "SSO\t%s\n", button.Provider)
+	}
+
+	levels := make([]string, 0, len(result.Headings))
+	for level := range result.Headings {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+	for _, level := range levels {
+		fmt.Fprintf(tw, "Headings %s\t%d\n", level, result.Headings[level])
+	}
+	for _, finding := range result.HeadingFindings {
+		fmt.Fprintf(tw, "Heading %s\t%s\n", finding.Severity, finding.Message)
+	}
+
+	if links := result.LinkSummary; links != nil {
+		fmt.Fprintf(tw, "Internal Links\t%d\n", links.InternalLinks)
+		fmt.Fprintf(tw, "External Links\t%d\n", links.ExternalLinks)
+		fmt.Fprintf(tw, "Accessible Links\t%d\n", links.AccessibleLinks)
+		fmt.Fprintf(tw, "Inaccessible Links\t%d\n", links.InaccessibleLinks)
+		fmt.Fprintf(tw, "Disallowed Links\t%d\n", links.DisallowedLinks)
+		fmt.Fprintf(tw, "Unchecked Links\t%d\n", links.UncheckedLinks)
+	}
+
+	if seo := result.SEO; seo != nil {
+		fmt.Fprintf(tw, "Description\t%s\n", seo.Description)
+		fmt.Fprintf(tw, "Canonical\t%s\n", seo.Canonical)
+		fmt.Fprintf(tw, "Indexable\t%s\n", yesNo(seo.Indexable))
+		for _, finding := range seo.Findings {
+			fmt.Fprintf(tw, "SEO %s\t%s\n", finding.Severity, finding.Message)
+		}
+	}
+
+	if data := result.StructuredData; data != nil {
+		for _, entity := range data.Entities {
+			fmt.Fprintf(tw, "Structured Data\t%s %s\n", entity.Type, entity.Format)
+		}
+		for _, finding := range data.Findings {
+			fmt.Fprintf(tw, "Structured Data %s\t%s\n", finding.Severity, finding.Message)
+		}
+	}
+
+	if accessibility := result.Accessibility; accessibility != nil {
+		fmt.Fprintf(tw, "Accessibility\t%d%% of %d checks\n", accessibility.Score, accessibility.Checks)
+		for _, finding := range accessibility.Findings {
+			fmt.Fprintf(tw, "Accessibility\t%s: %s at %s\n", finding.Severity, finding.Message, finding.Path)
+		}
+	}
+
+	if len(result.TimedOut) > 0 {
+		fmt.Fprintf(tw, "Timed Out\t%s\n", strings.Join(result.TimedOut, ", "))
+	}
+	for _, err := range result.Errors {
+		fmt.Fprintf(tw, "Error\t%s\n", err)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	return writeBrokenLinks(w, result.LinkSummary)
+}
+
+// writeBrokenLinks lists the inaccessible links, they're what needs fixing.
+func writeBrokenLinks(w io.Writer, links *analyzer.LinkSummaryResponse) error {
+	if links == nil || links.InaccessibleLinks == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BROKEN LINK\tSTATUS\tCATEGORY")
+	for _, link := range links.Links {
+		if link.Accessible || link.Status.Category == analyzer.LinkDisallowed || link.Status.Category == analyzer.LinkUnresolved {
+			continue
+		}
+
+		status := "-"
+		if link.Status.StatusCode != 0 {
+			status = fmt.Sprint(link.Status.StatusCode)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", link.LinkUrl, status, link.Status.Category)
+	}
+
+	return tw.Flush()
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+	return "No"
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/server"
+	"gopkg.in/yaml.v3"
+)
+
+// environment variables are named after the flags, "server.addr" is WEB_ANALYZER_SERVER_ADDR
+const envPrefix = "WEB_ANALYZER_"
+
+// Config is everything the server can be configured with.
+// Every value comes from the defaults, the config file, the environment and the flags, the later ones win.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Log      LogConfig      `yaml:"log"`
+	Analyzer AnalyzerConfig `yaml:"analyzer"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	RequestTimeout  time.Duration `yaml:"request_timeout"`
+	CrawlTimeout    time.Duration `yaml:"crawl_timeout"`
+	BatchTimeout    time.Duration `yaml:"batch_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // time the requests in flight get on shutdown
+	Templates       string        `yaml:"templates"`        // glob of the UI templates, the UI isn't served when empty
+	Jobs            JobsConfig    `yaml:"jobs"`
+}
+
+type JobsConfig struct {
+	Workers   int           `yaml:"workers"`
+	QueueSize int           `yaml:"queue_size"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+type LogConfig struct {
+	Level slog.Level `yaml:"level"` // debug, info, warn or error
+}
+
+type AnalyzerConfig struct {
+	Timeout               time.Duration `yaml:"timeout"`
+	LinkTimeout           time.Duration `yaml:"link_timeout"`
+	MaxRedirects          int           `yaml:"max_redirects"`
+	UserAgent             string        `yaml:"user_agent"`
+	Proxy                 string        `yaml:"proxy"`
+	InsecureSkipVerify    bool          `yaml:"insecure_skip_verify"`
+	MaxConnsPerHost       int           `yaml:"max_conns_per_host"`
+	LinkCacheTTL          time.Duration `yaml:"link_cache_ttl"`
+	LinkCacheSize         int           `yaml:"link_cache_size"`
+	LinkCacheFile         string        `yaml:"link_cache_file"`
+	MaxConcurrentPerHost  int           `yaml:"max_concurrent_per_host"`
+	RequestsPerSecond     float64       `yaml:"requests_per_second"`
+	MaxRetryAfter         time.Duration `yaml:"max_retry_after"`
+	RespectRobots         bool          `yaml:"respect_robots"`
+	RobotsCacheTTL        time.Duration `yaml:"robots_cache_ttl"`
+	MaxBodySize           int64         `yaml:"max_body_size"`           // bytes, 0 means no limit
+	MaxHeaderSize         int64         `yaml:"max_header_size"`         // bytes
+	MaxDecompressionRatio float64       `yaml:"max_decompression_ratio"` // 0 means no limit
+	Egress                EgressConfig  `yaml:"egress"`
+}
+
+// EgressConfig keeps the submitted urls from reaching the internal network.
+type EgressConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	AllowPrivate bool     `yaml:"allow_private"` // allow private, loopback and link-local addresses
+	AllowHosts   []string `yaml:"allow_hosts"`   // only these hosts are allowed when set, "*.example.com" matches the subdomains
+	DenyHosts    []string `yaml:"deny_hosts"`
+	AllowPorts   []int    `yaml:"allow_ports"` // only these ports are allowed when set
+}
+
+// Default takes the defaults of the server and the analyzer.
+func Default() Config {
+	serverConfig := server.DefaultConfig()
+	analyzerConfig := analyzer.DefaultConfig()
+
+	return Config{
+		Server: ServerConfig{
+			Addr:            serverConfig.Addr,
+			RequestTimeout:  serverConfig.RequestTimeout,
+			CrawlTimeout:    serverConfig.CrawlTimeout,
+			BatchTimeout:    serverConfig.BatchTimeout,
+			ShutdownTimeout: 5 * time.Second,
+			Templates:       serverConfig.Templates,
+			Jobs: JobsConfig{
+				Workers:   serverConfig.JobWorkers,
+				QueueSize: serverConfig.JobQueueSize,
+				Timeout:   serverConfig.JobTimeout,
+				Retention: serverConfig.JobRetention,
+			},
+		},
+		Log: LogConfig{
+			Level: slog.LevelInfo,
+		},
+		Analyzer: AnalyzerConfig{
+			Timeout:               analyzerConfig.Timeout,
+			LinkTimeout:           analyzerConfig.LinkTimeout,
+			MaxRedirects:          analyzerConfig.MaxRedirects,
+			UserAgent:             analyzerConfig.UserAgent,
+			Proxy:                 analyzerConfig.Proxy,
+			InsecureSkipVerify:    analyzerConfig.InsecureSkipVerify,
+			MaxConnsPerHost:       analyzerConfig.MaxConnsPerHost,
+			LinkCacheTTL:          analyzerConfig.LinkCacheTTL,
+			LinkCacheSize:         analyzerConfig.LinkCacheSize,
+			LinkCacheFile:         analyzerConfig.LinkCacheFile,
+			MaxConcurrentPerHost:  analyzerConfig.RateLimit.MaxConcurrentPerHost,
+			RequestsPerSecond:     analyzerConfig.RateLimit.RequestsPerSecond,
+			MaxRetryAfter:         analyzerConfig.MaxRetryAfter,
+			RespectRobots:         analyzerConfig.RespectRobots,
+			RobotsCacheTTL:        analyzerConfig.RobotsCacheTTL,
+			MaxBodySize:           analyzerConfig.MaxBodySize,
+			MaxHeaderSize:         analyzerConfig.MaxHeaderSize,
+			MaxDecompressionRatio: analyzerConfig.MaxDecompressionRatio,
+			// the analyzer connects anywhere on its own, a server open to anyone shouldn't
+			Egress: EgressConfig{
+				Enabled:    true,
+				AllowPorts: []int{80, 443},
+			},
+		},
+	}
+}
+
+// Load reads the config from the file given by -config (or WEB_ANALYZER_CONFIG), the environment and the flags,
+// in that order, and validates it.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	config := Default()
+
+	flags := flag.NewFlagSet("go-web-analyzer", flag.ContinueOnError)
+	path := flags.String("config", "", "YAML or JSON config file")
+	config.bind(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// the flags are bound to the config, so they're set once more after the file and the environment
+	set := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	// reset in place, the flags are bound to the fields of this very struct.
+	// Declaring a new config here would leave them setting the old one.
+	config = Default()
+
+	if *path == "" {
+		*path, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if *path != "" {
+		if err := config.loadFile(*path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var envErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value, ok := lookupEnv(name)
+		if !ok || f.Name == "config" || envErr != nil {
+			return
+		}
+
+		if err := flags.Set(f.Name, value); err != nil {
+			envErr = fmt.Errorf("invalid %s: %w", name, err)
+		}
+	})
+	if envErr != nil {
+		return Config{}, envErr
+	}
+
+	for name, value := range set {
+		if err := flags.Set(name, value); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// loadFile overrides the config with the values of the file. JSON is valid YAML, so both are read the same way.
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// a typo in the file shouldn't be silently ignored
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, ".", "_"))
+}
+
+// Validate reports every invalid value at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.RequestTimeout > 0, "server.request_timeout must be positive")
+	check(c.Server.CrawlTimeout > 0, "server.crawl_timeout must be positive")
+	check(c.Server.BatchTimeout > 0, "server.batch_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.Jobs.Workers > 0, "server.jobs.workers must be positive")
+	check(c.Server.Jobs.QueueSize > 0, "server.jobs.queue_size must be positive")
+	check(c.Server.Jobs.Timeout > 0, "server.jobs.timeout must be positive")
+	check(c.Server.Jobs.Retention >= 0, "server.jobs.retention can't be negative")
+
+	check(c.Analyzer.Timeout > 0, "analyzer.timeout must be positive")
+	check(c.Analyzer.LinkTimeout > 0, "analyzer.link_timeout must be positive")
+	check(c.Analyzer.MaxRedirects >= 0, "analyzer.max_redirects can't be negative")
+	check(c.Analyzer.MaxConnsPerHost >= 0, "analyzer.max_conns_per_host can't be negative")
+	check(c.Analyzer.LinkCacheTTL >= 0, "analyzer.link_cache_ttl can't be negative")
+	check(c.Analyzer.LinkCacheSize >= 0, "analyzer.link_cache_size can't be negative")
+	check(c.Analyzer.MaxConcurrentPerHost >= 0, "analyzer.max_concurrent_per_host can't be negative")
+	check(c.Analyzer.RequestsPerSecond >= 0, "analyzer.requests_per_second can't be negative")
+	check(c.Analyzer.MaxRetryAfter >= 0, "analyzer.max_retry_after can't be negative")
+	check(c.Analyzer.RobotsCacheTTL >= 0, "analyzer.robots_cache_ttl can't be negative")
+	check(c.Analyzer.MaxBodySize >= 0, "analyzer.max_body_size can't be negative")
+	check(c.Analyzer.MaxHeaderSize > 0, "analyzer.max_header_size must be positive")
+	check(c.Analyzer.MaxDecompressionRatio >= 0, "analyzer.max_decompression_ratio can't be negative")
+
+	for _, port := range c.Analyzer.Egress.AllowPorts {
+		check(port > 0 && port <= 65535, "analyzer.egress.allow_ports has an invalid port %d", port)
+	}
+
+	if c.Analyzer.Proxy != "" {
+		proxyUrl, err := url.Parse(c.Analyzer.Proxy)
+		check(err == nil && proxyUrl.Scheme != "" && proxyUrl.Host != "", "analyzer.proxy %q isn't a valid url", c.Analyzer.Proxy)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) ServerConfig() server.Config {
+	return server.Config{
+		Addr:           c.Server.Addr,
+		RequestTimeout: c.Server.RequestTimeout,
+		CrawlTimeout:   c.Server.CrawlTimeout,
+		BatchTimeout:   c.Server.BatchTimeout,
+		Templates:      c.Server.Templates,
+		JobWorkers:     c.Server.Jobs.Workers,
+		JobQueueSize:   c.Server.Jobs.QueueSize,
+		JobTimeout:     c.Server.Jobs.Timeout,
+		JobRetention:   c.Server.Jobs.Retention,
+	}
+}
+
+func (c Config) AnalyzerConfig() analyzer.Config {
+	config := analyzer.DefaultConfig()
+	config.Timeout = c.Analyzer.Timeout
+	config.LinkTimeout = c.Analyzer.LinkTimeout
+	config.MaxRedirects = c.Analyzer.MaxRedirects
+	config.UserAgent = c.Analyzer.UserAgent
+	config.Proxy = c.Analyzer.Proxy
+	config.InsecureSkipVerify = c.Analyzer.InsecureSkipVerify
+	config.MaxConnsPerHost = c.Analyzer.MaxConnsPerHost
+	config.LinkCacheTTL = c.Analyzer.LinkCacheTTL
+	config.LinkCacheSize = c.Analyzer.LinkCacheSize
+	config.LinkCacheFile = c.Analyzer.LinkCacheFile
+	config.RateLimit = analyzer.RateLimitOptions{
+		MaxConcurrentPerHost: c.Analyzer.MaxConcurrentPerHost,
+		RequestsPerSecond:    c.Analyzer.RequestsPerSecond,
+	}
+	config.MaxRetryAfter = c.Analyzer.MaxRetryAfter
+	config.RespectRobots = c.Analyzer.RespectRobots
+	config.RobotsCacheTTL = c.Analyzer.RobotsCacheTTL
+	config.MaxBodySize = c.Analyzer.MaxBodySize
+	config.MaxHeaderSize = c.Analyzer.MaxHeaderSize
+	config.MaxDecompressionRatio = c.Analyzer.MaxDecompressionRatio
+
+	if c.Analyzer.Egress.Enabled {
+		config.Egress = &analyzer.EgressPolicy{
+			AllowPrivate: c.Analyzer.Egress.AllowPrivate,
+			AllowHosts:   c.Analyzer.Egress.AllowHosts,
+			DenyHosts:    c.Analyzer.Egress.DenyHosts,
+			AllowPorts:   c.Analyzer.Egress.AllowPorts,
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		config, err := Load(nil, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, Default(), config)
+		assert.Equal(t, ":8080", config.Server.Addr)
+		assert.Equal(t, 10*time.Second, config.Server.RequestTimeout)
+		assert.Equal(t, 3*time.Second, config.Analyzer.LinkTimeout)
+		assert.Equal(t, slog.LevelInfo, config.Log.Level)
+	})
+
+	t.Run("YAML file", func(t *testing.T) {
+		config, err := Load([]string{"-config", "testdata/config.yaml"}, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, ":9090", config.Server.Addr)
+		assert.Equal(t, 20*time.Second, config.Server.RequestTimeout)
+		assert.Equal(t, 8, config.Server.Jobs.Workers)
+		assert.Equal(t, 100, config.Server.Jobs.QueueSize)
+		assert.Equal(t, slog.LevelDebug, config.Log.Level)
+		assert.Equal(t, 5*time.Second, config.Analyzer.LinkTimeout)
+		assert.True(t, config.Analyzer.RespectRobots)
+	})
+
+	t.Run("JSON file from the environment", func(t *testing.T) {
+		config, err := Load(nil, env(map[string]string{"WEB_ANALYZER_CONFIG": "testdata/config.json"}))
+		assert.NoError(t, err)
+		assert.Equal(t, ":9091", config.Server.Addr)
+		assert.Empty(t, config.Server.Templates)
+		assert.Equal(t, 15*time.Second, config.Analyzer.Timeout)
+		assert.Equal(t, 2.5, config.Analyzer.RequestsPerSecond)
+	})
+
+	t.Run("Flags win over the environment which wins over the file", func(t *testing.T) {
+		config, err := Load(
+			[]string{"-config", "testdata/config.yaml", "-server.addr", ":7070"},
+			env(map[string]string{
+				"WEB_ANALYZER_SERVER_ADDR":            ":6060",
+				"WEB_ANALYZER_SERVER_REQUEST_TIMEOUT": "30s",
+				"WEB_ANALYZER_LOG_LEVEL":              "warn",
+			}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, ":7070", config.Server.Addr)
+		assert.Equal(t, 30*time.Second, config.Server.RequestTimeout)
+		assert.Equal(t, 8, config.Server.Jobs.Workers)
+		assert.Equal(t, slog.LevelWarn, config.Log.Level)
+	})
+
+	t.Run("Invalid environment value", func(t *testing.T) {
+		_, err := Load(nil, env(map[string]string{"WEB_ANALYZER_ANALYZER_TIMEOUT": "soon"}))
+		assert.ErrorContains(t, err, "WEB_ANALYZER_ANALYZER_TIMEOUT")
+	})
+
+	t.Run("Unknown field in the file", func(t *testing.T) {
+		_, err := Load([]string{"-config", "testdata/unknown.yaml"}, env(nil))
+		assert.ErrorContains(t, err, "field port not found")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Load([]string{"-config", "testdata/missing.yaml"}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		_, err := Load([]string{"-server.addr", "", "-analyzer.timeout", "-1s", "-analyzer.proxy", "not a url"}, env(nil))
+		assert.ErrorContains(t, err, "server.addr is required")
+		assert.ErrorContains(t, err, "analyzer.timeout must be positive")
+		assert.ErrorContains(t, err, "analyzer.proxy")
+	})
+}
+
+func TestLoad_Egress(t *testing.T) {
+	t.Run("Enabled by default", func(t *testing.T) {
+		config, err := Load(nil, env(nil))
+		assert.NoError(t, err)
+
+		policy := config.AnalyzerConfig().Egress
+		assert.NotNil(t, policy)
+		assert.False(t, policy.AllowPrivate)
+		assert.Equal(t, []int{80, 443}, policy.AllowPorts)
+	})
+
+	t.Run("Lists from the flags and the environment", func(t *testing.T) {
+		config, err := Load(
+			[]string{"-analyzer.egress.allow_ports", "443, 8443"},
+			env(map[string]string{"WEB_ANALYZER_ANALYZER_EGRESS_DENY_HOSTS": "metadata.internal,*.corp"}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{443, 8443}, config.Analyzer.Egress.AllowPorts)
+		assert.Equal(t, []string{"metadata.internal", "*.corp"}, config.Analyzer.Egress.DenyHosts)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		config, err := Load([]string{"-analyzer.egress.enabled=false"}, env(nil))
+		assert.NoError(t, err)
+		assert.Nil(t, config.AnalyzerConfig().Egress)
+	})
+
+	t.Run("Invalid port", func(t *testing.T) {
+		_, err := Load([]string{"-analyzer.egress.allow_ports", "80,70000"}, env(nil))
+		assert.ErrorContains(t, err, "invalid port 70000")
+	})
+}
+
+func TestAnalyzerConfig(t *testing.T) {
+	config := Default()
+	config.Analyzer.MaxConcurrentPerHost = 2
+	config.Analyzer.RequestsPerSecond = 5
+
+	analyzerConfig := config.AnalyzerConfig()
+	assert.Equal(t, 2, analyzerConfig.RateLimit.MaxConcurrentPerHost)
+	assert.Equal(t, 5.0, analyzerConfig.RateLimit.RequestsPerSecond)
+	assert.Equal(t, config.Analyzer.LinkTimeout, analyzerConfig.LinkTimeout)
+}
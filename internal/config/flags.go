@@ -0,0 +1,101 @@
+package config
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// bind registers a flag for every value of the config, named after its path in the config file.
+func (c *Config) bind(flags *flag.FlagSet) {
+	flags.StringVar(&c.Server.Addr, "server.addr", c.Server.Addr, "address the server listens on")
+	flags.DurationVar(&c.Server.RequestTimeout, "server.request_timeout", c.Server.RequestTimeout, "timeout of a single request")
+	flags.DurationVar(&c.Server.CrawlTimeout, "server.crawl_timeout", c.Server.CrawlTimeout, "timeout of a crawl request")
+	flags.DurationVar(&c.Server.BatchTimeout, "server.batch_timeout", c.Server.BatchTimeout, "timeout of a batch request")
+	flags.DurationVar(&c.Server.ShutdownTimeout, "server.shutdown_timeout", c.Server.ShutdownTimeout, "time the requests in flight get on shutdown")
+	flags.StringVar(&c.Server.Templates, "server.templates", c.Server.Templates, "glob of the UI templates, the UI isn't served when empty")
+	flags.IntVar(&c.Server.Jobs.Workers, "server.jobs.workers", c.Server.Jobs.Workers, "jobs run at once")
+	flags.IntVar(&c.Server.Jobs.QueueSize, "server.jobs.queue_size", c.Server.Jobs.QueueSize, "jobs waiting to run, more are rejected")
+	flags.DurationVar(&c.Server.Jobs.Timeout, "server.jobs.timeout", c.Server.Jobs.Timeout, "timeout of a single job")
+	flags.DurationVar(&c.Server.Jobs.Retention, "server.jobs.retention", c.Server.Jobs.Retention, "finished jobs are kept this long")
+
+	flags.TextVar(&c.Log.Level, "log.level", c.Log.Level, "log level: debug, info, warn or error")
+
+	flags.DurationVar(&c.Analyzer.Timeout, "analyzer.timeout", c.Analyzer.Timeout, "timeout of the page fetch")
+	flags.DurationVar(&c.Analyzer.LinkTimeout, "analyzer.link_timeout", c.Analyzer.LinkTimeout, "timeout of a single link check")
+	flags.IntVar(&c.Analyzer.MaxRedirects, "analyzer.max_redirects", c.Analyzer.MaxRedirects, "redirects followed before giving up")
+	flags.StringVar(&c.Analyzer.UserAgent, "analyzer.user_agent", c.Analyzer.UserAgent, "User-Agent header sent with every request")
+	flags.StringVar(&c.Analyzer.Proxy, "analyzer.proxy", c.Analyzer.Proxy, "proxy url, the environment proxy is used when empty")
+	flags.BoolVar(&c.Analyzer.InsecureSkipVerify, "analyzer.insecure_skip_verify", c.Analyzer.InsecureSkipVerify, "skip TLS certificate verification")
+	flags.IntVar(&c.Analyzer.MaxConnsPerHost, "analyzer.max_conns_per_host", c.Analyzer.MaxConnsPerHost, "connections kept open per host, 0 means no limit")
+	flags.DurationVar(&c.Analyzer.LinkCacheTTL, "analyzer.link_cache_ttl", c.Analyzer.LinkCacheTTL, "how long a link check result is reused")
+	flags.IntVar(&c.Analyzer.LinkCacheSize, "analyzer.link_cache_size", c.Analyzer.LinkCacheSize, "link check results kept in memory, 0 disables the cache")
+	flags.StringVar(&c.Analyzer.LinkCacheFile, "analyzer.link_cache_file", c.Analyzer.LinkCacheFile, "keeps the link check results in this file instead of memory")
+	flags.IntVar(&c.Analyzer.MaxConcurrentPerHost, "analyzer.max_concurrent_per_host", c.Analyzer.MaxConcurrentPerHost, "link checks sent to a host at once, 0 means no limit")
+	flags.Float64Var(&c.Analyzer.RequestsPerSecond, "analyzer.requests_per_second", c.Analyzer.RequestsPerSecond, "link checks sent to a host per second, 0 means no limit")
+	flags.DurationVar(&c.Analyzer.MaxRetryAfter, "analyzer.max_retry_after", c.Analyzer.MaxRetryAfter, "longest Retry-After of a rate limited link which is waited for")
+	flags.BoolVar(&c.Analyzer.RespectRobots, "analyzer.respect_robots", c.Analyzer.RespectRobots, "skip the page and links robots.txt disallows")
+	flags.DurationVar(&c.Analyzer.RobotsCacheTTL, "analyzer.robots_cache_ttl", c.Analyzer.RobotsCacheTTL, "how long the robots.txt of a host is reused")
+	flags.Int64Var(&c.Analyzer.MaxBodySize, "analyzer.max_body_size", c.Analyzer.MaxBodySize, "largest page body read in bytes, decompressed, 0 means no limit")
+	flags.Int64Var(&c.Analyzer.MaxHeaderSize, "analyzer.max_header_size", c.Analyzer.MaxHeaderSize, "largest response headers read in bytes")
+	flags.Float64Var(&c.Analyzer.MaxDecompressionRatio, "analyzer.max_decompression_ratio", c.Analyzer.MaxDecompressionRatio, "largest ratio of the decompressed page to the compressed one, 0 means no limit")
+
+	flags.BoolVar(&c.Analyzer.Egress.Enabled, "analyzer.egress.enabled", c.Analyzer.Egress.Enabled, "enforce the egress policy")
+	flags.BoolVar(&c.Analyzer.Egress.AllowPrivate, "analyzer.egress.allow_private", c.Analyzer.Egress.AllowPrivate, "allow private, loopback and link-local addresses")
+	flags.Var((*stringList)(&c.Analyzer.Egress.AllowHosts), "analyzer.egress.allow_hosts", "comma separated hosts which are allowed, all of them when empty")
+	flags.Var((*stringList)(&c.Analyzer.Egress.DenyHosts), "analyzer.egress.deny_hosts", "comma separated hosts which are denied")
+	flags.Var((*intList)(&c.Analyzer.Egress.AllowPorts), "analyzer.egress.allow_ports", "comma separated ports which are allowed, all of them when empty")
+}
+
+// stringList is a comma separated flag, setting it replaces the whole list.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	*l = list
+	return nil
+}
+
+type intList []int
+
+func (l *intList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	items := make([]string, 0, len(*l))
+	for _, item := range *l {
+		items = append(items, strconv.Itoa(item))
+	}
+	return strings.Join(items, ",")
+}
+
+func (l *intList) Set(value string) error {
+	var items stringList
+	items.Set(value)
+
+	list := make([]int, 0, len(items))
+	for _, item := range items {
+		number, err := strconv.Atoi(item)
+		if err != nil {
+			return err
+		}
+		list = append(list, number)
+	}
+
+	*l = list
+	return nil
+}
@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/observability"
+	"github.com/rs/xid"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is an analysis which runs in the background.
+type Job struct {
+	ID         string
+	Status     Status
+	Request    analyzer.AnalyzerRequest
+	Result     *analyzer.AnalyzerResponse // partial while the job is running
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+type AnalyzeFunc func(ctx context.Context, request analyzer.AnalyzerRequest) (*analyzer.AnalyzerResponse, error)
+
+// Manager runs the jobs on a bounded pool of workers.
+type Manager struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	queue     chan *Job
+	analyze   AnalyzeFunc
+	timeout   time.Duration // timeout of a single job
+	retention time.Duration // finished jobs are kept this long
+	logger    *slog.Logger
+	ctx       context.Context
+	stop      context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewManager(workers, queueSize int, timeout, retention time.Duration, analyze AnalyzeFunc, logger *slog.Logger) *Manager {
+	ctx, stop := context.WithCancel(context.Background())
+	m := &Manager{
+		jobs:      make(map[string]*Job),
+		queue:     make(chan *Job, queueSize),
+		analyze:   analyze,
+		timeout:   timeout,
+		retention: retention,
+		logger:    logger,
+		ctx:       ctx,
+		stop:      stop,
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+// Submit queues the request and returns the job right away.
+func (m *Manager) Submit(request analyzer.AnalyzerRequest) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune()
+
+	ctx, cancel := context.WithTimeout(m.ctx, m.timeout)
+	job := &Job{
+		ID:        xid.New().String(),
+		Status:    StatusQueued,
+		Request:   request,
+		CreatedAt: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	select {
+	case m.queue <- job:
+	default:
+		cancel()
+		return Job{}, ErrQueueFull
+	}
+
+	m.jobs[job.ID] = job
+	observability.JobsQueueDepth.Set(float64(len(m.queue)))
+	return *job, nil
+}
+
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	return *job, nil
+}
+
+// Cancel stops the job, a queued job won't be started at all.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	if job.Status == StatusQueued {
+		m.finish(job, StatusCancelled)
+	}
+	job.cancel()
+
+	return *job, nil
+}
+
+// Stop cancels the running jobs and waits for the workers to return.
+func (m *Manager) Stop() {
+	m.stop()
+	m.wg.Wait()
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case job := <-m.queue:
+			observability.JobsQueueDepth.Set(float64(len(m.queue)))
+			m.run(job)
+		}
+	}
+}
+
+func (m *Manager) run(job *Job) {
+	m.mu.Lock()
+	if job.Status != StatusQueued {
+		// cancelled while it was waiting in the queue
+		m.mu.Unlock()
+		return
+	}
+	job.Status = StatusRunning
+	job.StartedAt = time.Now()
+	m.mu.Unlock()
+
+	observability.JobsRunning.Inc()
+	defer observability.JobsRunning.Dec()
+
+	log := m.logger.With(slog.String("job_id", job.ID))
+	ctx := logger.SetLogger(job.ctx, log)
+	ctx = analyzer.WithProgress(ctx, func(progress analyzer.Progress) {
+		if progress.Result == nil {
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		job.Result = progress.Result
+	})
+
+	result, err := m.analyze(ctx, job.Request)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer job.cancel()
+
+	switch {
+	case errors.Is(job.ctx.Err(), context.Canceled):
+		if result != nil {
+			job.Result = result
+		}
+		m.finish(job, StatusCancelled)
+	case err != nil:
+		job.Error = err.Error()
+		m.finish(job, StatusFailed)
+	default:
+		job.Result = result
+		m.finish(job, StatusDone)
+	}
+
+	log.Info("Job finished", slog.String("status", string(job.Status)))
+}
+
+func (m *Manager) finish(job *Job, status Status) {
+	job.Status = status
+	job.FinishedAt = time.Now()
+	observability.JobsTotal.WithLabelValues(string(status)).Inc()
+}
+
+// prune drops the finished jobs which are older than the retention.
+func (m *Manager) prune() {
+	for id, job := range m.jobs {
+		if job.finished() && time.Since(job.FinishedAt) > m.retention {
+			delete(m.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// blockingAnalyze runs until its context is done.
+func blockingAnalyze(ctx context.Context, request analyzer.AnalyzerRequest) (*analyzer.AnalyzerResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func waitFor(t *testing.T, m *Manager, id string, done func(Job) bool) Job {
+	t.Helper()
+
+	var job Job
+	assert.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		return err == nil && done(job)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return job
+}
+
+func TestManager_Submit(t *testing.T) {
+	analyze := func(ctx context.Context, request analyzer.AnalyzerRequest) (*analyzer.AnalyzerResponse, error) {
+		if request.Url == "http://fail.test" {
+			return nil, fmt.Errorf("failed to fetch")
+		}
+		return &analyzer.AnalyzerResponse{PageTitle: "Done"}, nil
+	}
+
+	m := NewManager(2, 10, time.Minute, time.Hour, analyze, testLogger)
+	defer m.Stop()
+
+	t.Run("Job is done", func(t *testing.T) {
+		job, err := m.Submit(analyzer.AnalyzerRequest{Url: "http://example.test"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, job.ID)
+
+		job = waitFor(t, m, job.ID, func(j Job) bool { return j.Status == StatusDone })
+		assert.Equal(t, "Done", job.Result.PageTitle)
+		assert.False(t, job.FinishedAt.IsZero())
+	})
+
+	t.Run("Job failed", func(t *testing.T) {
+		job, err := m.Submit(analyzer.AnalyzerRequest{Url: "http://fail.test"})
+		assert.NoError(t, err)
+
+		job = waitFor(t, m, job.ID, func(j Job) bool { return j.Status == StatusFailed })
+		assert.Equal(t, "failed to fetch", job.Error)
+		assert.Nil(t, job.Result)
+	})
+
+	t.Run("Unknown job", func(t *testing.T) {
+		_, err := m.Get("unknown")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+
+		_, err = m.Cancel("unknown")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+	})
+}
+
+func TestManager_Cancel(t *testing.T) {
+	m := NewManager(1, 10, time.Minute, time.Hour, blockingAnalyze, testLogger)
+	defer m.Stop()
+
+	running, err := m.Submit(analyzer.AnalyzerRequest{Url: "http://running.test"})
+	assert.NoError(t, err)
+	waitFor(t, m, running.ID, func(j Job) bool { return j.Status == StatusRunning })
+
+	// the only worker is busy, so this one stays in the queue
+	queued, err := m.Submit(analyzer.AnalyzerRequest{Url: "http://queued.test"})
+	assert.NoError(t, err)
+
+	job, err := m.Cancel(queued.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCancelled, job.Status)
+
+	_, err = m.Cancel(running.ID)
+	assert.NoError(t, err)
+	waitFor(t, m, running.ID, func(j Job) bool { return j.Status == StatusCancelled })
+
+	job, err = m.Get(queued.ID)
+	assert.NoError(t, err)
+	assert.True(t, job.StartedAt.IsZero())
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	m := NewManager(1, 1, time.Minute, time.Hour, blockingAnalyze, testLogger)
+	defer m.Stop()
+
+	first, err := m.Submit(analyzer.AnalyzerRequest{Url: "http://first.test"})
+	assert.NoError(t, err)
+	waitFor(t, m, first.ID, func(j Job) bool { return j.Status == StatusRunning })
+
+	_, err = m.Submit(analyzer.AnalyzerRequest{Url: "http://second.test"})
+	assert.NoError(t, err)
+
+	_, err = m.Submit(analyzer.AnalyzerRequest{Url: "http://third.test"})
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestManager_PartialResult(t *testing.T) {
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slowServer.Close()
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<!DOCTYPE html><html><head><title>Partial</title></head><body><a href="%s">Slow</a></body></html>`, slowServer.URL)
+	}))
+	defer testServer.Close()
+
+	m := NewManager(1, 1, time.Minute, time.Hour, analyzer.Analyze, testLogger)
+	defer m.Stop()
+
+	job, err := m.Submit(analyzer.AnalyzerRequest{Url: testServer.URL})
+	assert.NoError(t, err)
+
+	// the title is there while the link check is still running
+	job = waitFor(t, m, job.ID, func(j Job) bool { return j.Result != nil && j.Result.PageTitle != "" })
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.Equal(t, "Partial", job.Result.PageTitle)
+	assert.Nil(t, job.Result.LinkSummary)
+
+	_, err = m.Cancel(job.ID)
+	assert.NoError(t, err)
+
+	job = waitFor(t, m, job.ID, func(j Job) bool { return j.Status == StatusCancelled })
+	assert.Equal(t, "Partial", job.Result.PageTitle)
+	assert.Contains(t, job.Result.TimedOut, analyzer.LinksAnalysis)
+}
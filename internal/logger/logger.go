@@ -8,9 +8,9 @@ import (
 
 var loggerKey = "analyzer-logger"
 
-func New() *slog.Logger {
+func New(level slog.Level) *slog.Logger {
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo, // TODO to make it configurable
+		Level: level,
 	})
 
 	return slog.New(handler)
@@ -25,5 +25,5 @@ func FromContext(ctx context.Context) *slog.Logger {
 		return logger
 	}
 
-	return New()
+	return New(slog.LevelInfo)
 }
@@ -13,10 +13,62 @@ var (
 		},
 		[]string{"function", "status"},
 	)
+
+	LinkCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "link_cache_hits_total",
+			Help: "Link checks answered from the cache",
+		},
+	)
+
+	LinkCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "link_cache_misses_total",
+			Help: "Link checks not found in the cache",
+		},
+	)
+
+	JobsQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jobs_queue_depth",
+			Help: "Analysis jobs waiting for a worker",
+		},
+	)
+
+	JobsRunning = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "jobs_running",
+			Help: "Analysis jobs being run",
+		},
+	)
+
+	JobsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_total",
+			Help: "Analysis jobs finished by their status",
+		},
+		[]string{"status"},
+	)
+
+	FetchLimitsExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fetch_limits_exceeded_total",
+			Help: "Page fetches stopped by a limit, by the limit",
+		},
+		[]string{"limit"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(DurationMetrics)
+	prometheus.MustRegister(
+		DurationMetrics,
+		LinkCacheHits,
+		LinkCacheMisses,
+		JobsQueueDepth,
+		JobsRunning,
+		JobsTotal,
+		FetchLimitsExceeded,
+	)
 }
 
 func GetDurationMetrics() *prometheus.HistogramVec {
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) createJobHandler(c *gin.Context) {
+	var req analyzer.AnalyzerRequest
+	err := c.ShouldBindBodyWithJSON(&req)
+	if err != nil {
+		s.logger.Error("Invalid request", slog.Any("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Error": "Invalid request",
+		})
+		return
+	}
+
+	job, err := s.jobs.Submit(req)
+	if err != nil {
+		s.logger.Error("Failed to queue the job", slog.Any("error", err.Error()))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"Error": "Too many jobs, try again later",
+		})
+		return
+	}
+
+	c.Header("Location", "/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (s *Server) getJobHandler(c *gin.Context) {
+	job, err := s.jobs.Get(c.Param("id"))
+	if err != nil {
+		s.writeJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (s *Server) cancelJobHandler(c *gin.Context) {
+	job, err := s.jobs.Cancel(c.Param("id"))
+	if err != nil {
+		s.writeJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (s *Server) writeJobError(c *gin.Context, err error) {
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"Error": "Job not found",
+		})
+		return
+	}
+
+	s.logger.Error("Internal Server Error", slog.Any("error", err.Error()))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"Error": "Internal Server Error",
+	})
+}
@@ -1,13 +1,17 @@
 package server
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/Jawadh-Salih/go-web-analyzer/errors"
 	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
 	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/xid"
@@ -32,19 +36,31 @@ func (s *Server) setupMiddleware() {
 	})
 }
 
-func (s *Server) registerRoutes(withTemplates bool) {
-	if withTemplates {
-		s.router.LoadHTMLGlob("web/*.html")
+func (s *Server) registerRoutes(templates string) {
+	if templates != "" {
+		s.router.LoadHTMLGlob(templates)
 	}
 
-	s.router.GET("/", func(c *gin.Context) {
+	// crawls and batches analyze many pages, they'd hardly ever finish within the request timeout
+	s.router.POST("/crawl", middleware.TimeoutMiddleware(s.crawlTimeout), s.crawlHandler)
+	s.router.POST("/batch", middleware.TimeoutMiddleware(s.batchTimeout), s.batchHandler)
+
+	routes := s.router.Group("/", middleware.TimeoutMiddleware(s.requestTimeout))
+
+	routes.GET("/", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "index.html", gin.H{})
 	})
 
-	s.router.POST("/analyze", s.analyzeHandler)
+	routes.POST("/analyze", s.analyzeHandler)
+	routes.GET("/analyze/stream", s.analyzeStreamHandler)
+	routes.POST("/analyze/html", s.analyzeHTMLHandler)
+
+	routes.POST("/jobs", s.createJobHandler)
+	routes.GET("/jobs/:id", s.getJobHandler)
+	routes.DELETE("/jobs/:id", s.cancelJobHandler)
 
 	// Observability
-	s.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	routes.GET("/metrics", gin.WrapH(promhttp.Handler()))
 }
 
 func (s *Server) analyzeHandler(c *gin.Context) {
@@ -61,27 +77,182 @@ func (s *Server) analyzeHandler(c *gin.Context) {
 	// Should we pass the same logger to the Analyze function?
 	log := s.logger.With(slog.String("request_id", getRequestID(c)))
 	ctx := logger.SetLogger(c.Request.Context(), log)
-	result, err := analyzer.Analyze(ctx, req)
+	result, err := s.analyzer.Analyze(ctx, req)
+	if err != nil {
+		s.writeAnalyzeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// uploaded HTML over this size is rejected
+const maxHTMLSize = 10 << 20
+
+// analyzeHTMLHandler analyzes the uploaded HTML. It's either the "file" of a multipart form
+// or the raw body, with the options in the form or the query.
+func (s *Server) analyzeHTMLHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxHTMLSize)
+
+	var req analyzer.HTMLRequest
+	err := c.ShouldBind(&req)
 	if err != nil {
-		// cast the error and see if it's an HttpApiError
-		// if not 500, if return the relevant code
-		if httpErr, ok := err.(errors.HttpError); ok {
-			c.JSON(httpErr.StatusCode(), gin.H{
-				"Error": fmt.Sprintf("Something went wrong with the URL - Status %d", httpErr.StatusCode()),
-			})
-			return
+		s.writeUploadError(c, err)
+		return
+	}
+
+	body, contentType, err := readHTML(c)
+	if err != nil {
+		s.writeUploadError(c, err)
+		return
+	}
+	req.ContentType = contentType
+
+	log := s.logger.With(slog.String("request_id", getRequestID(c)))
+	ctx := logger.SetLogger(c.Request.Context(), log)
+	result, err := s.analyzer.AnalyzeHTML(ctx, body, req)
+	if err != nil {
+		s.writeAnalyzeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// readHTML returns the uploaded HTML along with its content type, which may tell its charset.
+func readHTML(c *gin.Context) ([]byte, string, error) {
+	var body []byte
+	var err error
+	contentType := c.GetHeader("Content-Type")
+
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
 		}
 
-		s.logger.Error("Internal Server Error", slog.Any("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"Error": "Internal Server Error",
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+
+		contentType = header.Header.Get("Content-Type")
+
+		body, err = io.ReadAll(file)
+	} else {
+		body, err = io.ReadAll(c.Request.Body)
+	}
+
+	if err == nil && len(body) == 0 {
+		err = stderrors.New("empty HTML")
+	}
+
+	return body, contentType, err
+}
+
+func (s *Server) writeUploadError(c *gin.Context, err error) {
+	s.logger.Error("Invalid request", slog.Any("error", err.Error()))
+
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"Error": "The HTML is too large",
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"Error": "Invalid request",
+	})
+}
+
+func (s *Server) crawlHandler(c *gin.Context) {
+	var req analyzer.CrawlRequest
+	err := c.ShouldBindBodyWithJSON(&req)
+	if err != nil {
+		s.logger.Error("Invalid request", slog.Any("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Error": "Invalid request",
+		})
+		return
+	}
+
+	log := s.logger.With(slog.String("request_id", getRequestID(c)))
+	ctx := logger.SetLogger(c.Request.Context(), log)
+	result, err := s.analyzer.Crawl(ctx, req)
+	if err != nil {
+		s.writeAnalyzeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *Server) batchHandler(c *gin.Context) {
+	var req analyzer.BatchRequest
+	err := c.ShouldBindBodyWithJSON(&req)
+	if err != nil {
+		s.logger.Error("Invalid request", slog.Any("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Error": "Invalid request",
 		})
 		return
 	}
 
+	log := s.logger.With(slog.String("request_id", getRequestID(c)))
+	ctx := logger.SetLogger(c.Request.Context(), log)
+	result, err := s.analyzer.Batch(ctx, req)
+	if err != nil {
+		s.writeAnalyzeError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, result)
 }
 
+// writeAnalyzeError maps the errors of the analyzer to a response.
+func (s *Server) writeAnalyzeError(c *gin.Context, err error) {
+	status, message := s.analyzeErrorResponse(err)
+	c.JSON(status, gin.H{
+		"Error": message,
+	})
+}
+
+// analyzeErrorResponse returns the status and the message the error is reported with.
+func (s *Server) analyzeErrorResponse(err error) (int, string) {
+	if stderrors.Is(err, analyzer.ErrUnknownAnalysis) || stderrors.Is(err, analyzer.ErrTooManyUrls) {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	if stderrors.Is(err, analyzer.ErrDisallowedByRobots) {
+		return http.StatusForbidden, "The URL is disallowed by its robots.txt"
+	}
+
+	var egressErr *analyzer.EgressError
+	if stderrors.As(err, &egressErr) {
+		return http.StatusForbidden, "The URL is not allowed, " + egressErr.Error()
+	}
+
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, "Timed out on reaching the URL"
+	}
+
+	var limitErr *errors.LimitError
+	if stderrors.As(err, &limitErr) {
+		return limitErr.StatusCode(), limitErr.Error()
+	}
+
+	// cast the error and see if it's an HttpApiError
+	// if not 500, if return the relevant code
+	if httpErr, ok := err.(errors.HttpError); ok {
+		return httpErr.StatusCode(), fmt.Sprintf("Something went wrong with the URL - Status %d", httpErr.StatusCode())
+	}
+
+	s.logger.Error("Internal Server Error", slog.Any("error", err.Error()))
+	return http.StatusInternalServerError, "Internal Server Error"
+}
+
 func getRequestID(c *gin.Context) string {
 	if val, ok := c.Get("request_id"); ok {
 		if id, ok := val.(string); ok {
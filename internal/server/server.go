@@ -2,40 +2,79 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/Jawadh-Salih/go-web-analyzer/internal/middleware"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/jobs"
 	"github.com/gin-gonic/gin"
 )
 
 type Server struct {
-	port          string
-	logger        *slog.Logger
-	svr           *http.Server
-	router        *gin.Engine
-	withTemplates bool
+	port     string
+	logger   *slog.Logger
+	svr      *http.Server
+	router   *gin.Engine
+	analyzer *analyzer.Analyzer
+	jobs     *jobs.Manager
+
+	requestTimeout time.Duration
+	crawlTimeout   time.Duration
+	batchTimeout   time.Duration
+}
+
+// Config configures the HTTP server and the background jobs.
+type Config struct {
+	Addr           string        // address the server listens on
+	RequestTimeout time.Duration // timeout of a single request
+	CrawlTimeout   time.Duration // timeout of a crawl request, it analyzes many pages
+	BatchTimeout   time.Duration // timeout of a batch request, it analyzes many urls
+	Templates      string        // glob of the UI templates, the UI isn't served when empty
+	JobWorkers     int           // jobs run at once
+	JobQueueSize   int           // jobs waiting to run, more are rejected
+	JobTimeout     time.Duration // timeout of a single job
+	JobRetention   time.Duration // finished jobs are kept this long
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:           ":8080",
+		RequestTimeout: 10 * time.Second,
+		CrawlTimeout:   2 * time.Minute,
+		BatchTimeout:   2 * time.Minute,
+		Templates:      "web/*.html",
+		JobWorkers:     4,
+		JobQueueSize:   100,
+		JobTimeout:     2 * time.Minute,
+		JobRetention:   time.Hour,
+	}
 }
 
-func New(port string, logger *slog.Logger, withTemplates bool) *Server {
+func New(config Config, analyzer *analyzer.Analyzer, logger *slog.Logger) *Server {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(middleware.TimeoutMiddleware(10 * time.Second))
 
 	s := &Server{
-		port:   port,
-		router: r,
-		logger: logger,
+		port:     config.Addr,
+		router:   r,
+		logger:   logger,
+		analyzer: analyzer,
+		// jobs aren't bound by the request timeout, they have their own
+		jobs:           jobs.NewManager(config.JobWorkers, config.JobQueueSize, config.JobTimeout, config.JobRetention, analyzer.Analyze, logger),
+		requestTimeout: config.RequestTimeout,
+		crawlTimeout:   config.CrawlTimeout,
+		batchTimeout:   config.BatchTimeout,
 	}
 
 	s.setupMiddleware()
-	s.registerRoutes(withTemplates)
+	s.registerRoutes(config.Templates)
 
 	svr := &http.Server{
-		Addr:    port,
+		Addr:    config.Addr,
 		Handler: r,
 	}
 
@@ -44,11 +83,18 @@ func New(port string, logger *slog.Logger, withTemplates bool) *Server {
 }
 
 func (s *Server) Start() error {
-	return s.router.Run(s.port)
+	// serve through svr so that Stop can shut it down gracefully
+	if err := s.svr.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
 
 func (s *Server) Stop(ctx context.Context) error {
 	// Implement graceful shutdown logic if needed
 	s.logger.Info("Server is stopping...")
-	return s.svr.Shutdown(ctx)
+	err := s.svr.Shutdown(ctx)
+	s.jobs.Stop()
+	return err
 }
@@ -1,21 +1,33 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"log/slog"
 
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
+// the tests don't serve the UI, the templates aren't found from the package directory
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Templates = ""
+	return config
+}
+
 func TestNewServer(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(nil, nil))
-	server := New(":8080", logger, false)
+	server := New(testConfig(), analyzer.Default(), logger)
 
 	assert.NotNil(t, server)
 	assert.Equal(t, ":8080", server.port)
@@ -25,7 +37,7 @@ func TestNewServer(t *testing.T) {
 
 func TestServerStart(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	server := New(":8080", logger, false)
+	server := New(testConfig(), analyzer.Default(), logger)
 
 	go func() {
 		err := server.Start()
@@ -47,7 +59,7 @@ func TestServerStart(t *testing.T) {
 
 func TestServerMiddleware(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	server := New(":8080", logger, false)
+	server := New(testConfig(), analyzer.Default(), logger)
 
 	// Add a test route to verify middleware
 	server.router.GET("/ping", func(c *gin.Context) {
@@ -64,3 +76,315 @@ func TestServerMiddleware(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "pong")
 }
+
+func TestJobsRoutes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(testConfig(), analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	t.Run("Invalid job request", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"url":"not a url"}`))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown job", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/jobs/unknown", nil)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Job is created", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"url":"http://unknown-host.invalid"}`))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Header().Get("Location"), "/jobs/")
+		assert.Contains(t, w.Body.String(), `"ID"`)
+	})
+}
+
+func TestAnalyzeStream(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(testConfig(), analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<!DOCTYPE html><html><head><title>Stream</title></head><body><a href="/about">About</a></body></html>`))
+	}))
+	defer page.Close()
+
+	ts := httptest.NewServer(server.router)
+	defer ts.Close()
+
+	t.Run("Invalid stream request", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/analyze/stream?url=not-a-url")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Progress is streamed", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/analyze/stream?url=" + page.URL)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Contains(t, string(body), "event:analysis")
+		assert.Contains(t, string(body), "event:link")
+		assert.Contains(t, string(body), `"LinksChecked":1,"LinksTotal":1`)
+
+		// the result is always the last event
+		events := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+		assert.True(t, strings.HasPrefix(events[len(events)-1], "event:result"))
+		assert.Contains(t, events[len(events)-1], `"PageTitle":"Stream"`)
+	})
+
+	t.Run("Error is streamed", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/analyze/stream?url=" + page.URL + "&analyses=unknown")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Contains(t, string(body), "event:failed")
+		assert.Contains(t, string(body), `"Status":400`)
+	})
+}
+
+func TestAnalyzeStream_TimedOut(t *testing.T) {
+	config := testConfig()
+	config.RequestTimeout = 100 * time.Millisecond
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(config, analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<!DOCTYPE html><html><head><title>Stream</title></head><body><a href="/slow">Slow</a></body></html>`))
+	}))
+	defer page.Close()
+
+	ts := httptest.NewServer(server.router)
+	defer ts.Close()
+
+	// the partial result is what matters once the analysis timed out, it has to make it every time
+	for range 10 {
+		resp, err := http.Get(ts.URL + "/analyze/stream?url=" + page.URL)
+		assert.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+
+		events := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+		assert.True(t, strings.HasPrefix(events[len(events)-1], "event:result"), string(body))
+		assert.Contains(t, events[len(events)-1], `"TimedOut":["links"]`)
+	}
+}
+
+func TestBatchRoute(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(testConfig(), analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	t.Run("Invalid url in the batch", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"urls":["http://example.com","not a url"]}`))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too many urls", func(t *testing.T) {
+		urls := strings.Repeat(`"http://example.com",`, 51)
+		req, _ := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"urls":[`+strings.TrimSuffix(urls, ",")+`]}`))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Batch isn't bound by the request timeout", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte("<!DOCTYPE html><html><head><title>Slow</title></head><body></body></html>"))
+		}))
+		defer testServer.Close()
+
+		config := testConfig()
+		config.RequestTimeout = 10 * time.Millisecond
+		config.BatchTimeout = 5 * time.Second
+		server := New(config, analyzer.Default(), logger)
+		defer server.Stop(context.Background())
+
+		body := `{"urls":["` + testServer.URL + `","` + testServer.URL + `/other"]}`
+		req, _ := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"Failed":0`)
+	})
+
+	t.Run("Failed url doesn't fail the batch", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"urls":["http://unknown-host.invalid"]}`))
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"Failed":1`)
+	})
+}
+
+func TestAnalyzeHTMLRoute(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(testConfig(), analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	page := `<!DOCTYPE html><html><head><title>Upload</title></head><body><h1>Upload</h1></body></html>`
+
+	t.Run("Multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("base_url", "http://example.com")
+		form.WriteField("analyses", "title")
+		file, _ := form.CreateFormFile("file", "index.html")
+		file.Write([]byte(page))
+		form.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"PageTitle":"Upload"`)
+		assert.Contains(t, w.Body.String(), `"Analyses":["title"]`)
+	})
+
+	t.Run("Raw body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html?analyses=headings", strings.NewReader(page))
+		req.Header.Set("Content-Type", "text/html")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"Headings":{"h1":1}`)
+	})
+
+	t.Run("Charset of the raw body", func(t *testing.T) {
+		latin1 := "<html><head><title>Caf\xe9</title></head></html>"
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html?analyses=title", strings.NewReader(latin1))
+		req.Header.Set("Content-Type", "text/html; charset=iso-8859-1")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"PageTitle":"Café"`)
+		assert.Contains(t, w.Body.String(), `"Encoding":"windows-1252"`)
+	})
+
+	t.Run("Empty body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html", strings.NewReader(""))
+		req.Header.Set("Content-Type", "text/html")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid base url", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html?base_url=not-a-url", strings.NewReader(page))
+		req.Header.Set("Content-Type", "text/html")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze/html", strings.NewReader(strings.Repeat("a", maxHTMLSize+1)))
+		req.Header.Set("Content-Type", "text/html")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestEgressBlocked(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	config := analyzer.DefaultConfig()
+	config.Egress = &analyzer.EgressPolicy{}
+	a, err := analyzer.New(config)
+	assert.NoError(t, err)
+
+	server := New(testConfig(), a, logger)
+	defer server.Stop(context.Background())
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer page.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{"url":"`+page.URL+`"}`))
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "address isn't public")
+}
+
+func TestCrawlRoute(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("<!DOCTYPE html><html><head><title>Slow</title></head><body></body></html>"))
+	}))
+	defer testServer.Close()
+
+	// a crawl has its own timeout, the request timeout would cut it off
+	config := testConfig()
+	config.RequestTimeout = 10 * time.Millisecond
+	config.CrawlTimeout = 5 * time.Second
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(config, analyzer.Default(), logger)
+	defer server.Stop(context.Background())
+
+	body := `{"url":"` + testServer.URL + `","max_depth":0}`
+	req, _ := http.NewRequest(http.MethodPost, "/crawl", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"TimedOut":false`)
+	assert.Contains(t, w.Body.String(), `"PageTitle":"Slow"`)
+
+	req, _ = http.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{"url":"`+testServer.URL+`"}`))
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
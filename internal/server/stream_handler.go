@@ -0,0 +1,95 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/analyzer"
+	"github.com/Jawadh-Salih/go-web-analyzer/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// progress events waiting to be written to the client
+const streamBuffer = 16
+
+type streamEvent struct {
+	name string
+	data any
+}
+
+// analyzeStreamHandler runs the analysis of the query and streams its progress as Server-Sent Events.
+// "analysis" is sent when an analysis finishes, "link" when a link is checked,
+// and the stream ends with either "result" or "failed".
+func (s *Server) analyzeStreamHandler(c *gin.Context) {
+	var req analyzer.AnalyzerRequest
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		s.logger.Error("Invalid request", slog.Any("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Error": "Invalid request",
+		})
+		return
+	}
+
+	log := s.logger.With(slog.String("request_id", getRequestID(c)))
+	ctx := logger.SetLogger(c.Request.Context(), log)
+
+	events := make(chan streamEvent, streamBuffer)
+
+	// timed out extractors can still report after the analysis returned, those are dropped
+	var mu sync.Mutex
+	closed := false
+	// the last event isn't sent on events, it'd race with ctx.Done() once the request timed out
+	var last streamEvent
+	send := func(event streamEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	ctx = analyzer.WithProgress(ctx, func(progress analyzer.Progress) {
+		if progress.Result != nil {
+			send(streamEvent{name: "analysis", data: progress})
+		} else {
+			send(streamEvent{name: "link", data: progress})
+		}
+	})
+
+	go func() {
+		result, err := s.analyzer.Analyze(ctx, req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			status, message := s.analyzeErrorResponse(err)
+			last = streamEvent{name: "failed", data: gin.H{"Status": status, "Error": message}}
+		} else {
+			last = streamEvent{name: "result", data: result}
+		}
+		closed = true
+		close(events)
+	}()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			// last is set before events is closed
+			c.SSEvent(last.name, last.data)
+			return false
+		}
+
+		c.SSEvent(event.name, event.data)
+		return true
+	})
+}